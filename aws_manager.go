@@ -2,31 +2,53 @@ package aws_manager
 
 import (
 	"context"
+	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager/s3manageriface"
-	"github.com/kelseyhightower/envconfig"
 	"io"
+	"net/url"
 	"os"
 	"time"
 )
 
 type AwsManagerInterface interface {
 	Upload(context.Context, *UploadInput, ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error)
-	Download(context.Context, string, *DownloadInput, ...func(*s3manager.Downloader)) (int64, error)
+	Download(context.Context, string, *DownloadInput, ...func(*s3manager.Downloader)) (*DownloadOutput, error)
+	Delete(context.Context, *DeleteInput) (*s3.DeleteObjectOutput, error)
+	DeleteMany(context.Context, *DeleteManyInput) (*DeleteManyOutput, error)
+	Stat(context.Context, *StatInput) (*ObjectInfo, error)
+	Exists(context.Context, string) (bool, error)
+	List(context.Context, *ListInput) *ListIterator
+	Copy(context.Context, *CopyInput) (*CopyOutput, error)
+	Move(context.Context, *CopyInput) (*CopyOutput, error)
+	PresignGet(string, time.Duration, *DownloadInput) (*PresignOutput, error)
+	PresignPut(string, time.Duration, *UploadInput) (*PresignOutput, error)
+	NewPostPolicy(string, time.Duration) *PostPolicy
+	Open(context.Context, *DownloadInput) (io.ReadCloser, *ObjectInfo, error)
+	DownloadTo(context.Context, io.WriterAt, *DownloadInput, ...func(*s3manager.Downloader)) (*DownloadOutput, error)
+	UploadDir(context.Context, string, string, *UploadDirInput) (*UploadDirOutput, error)
+	DownloadPrefix(context.Context, string, string, *DownloadPrefixInput) (*DownloadPrefixOutput, error)
+	Sync(context.Context, string, string, *SyncInput) (*SyncOutput, error)
 }
 
 type AwsManager struct {
 	cfg           *Options
+	credentials   *credentials.Credentials
+	awsS3         s3iface.S3API
 	awsUploader   s3manageriface.UploaderAPI
 	awsDownloader s3manageriface.DownloaderAPI
+	limiter       *rateLimiter
 }
 
 type UploadInput struct {
 	ACL                       string
+	BandwidthLimit            int64
 	Body                      io.Reader
 	Path                      string
 	Bucket                    string
@@ -46,6 +68,8 @@ type UploadInput struct {
 	ObjectLockLegalHoldStatus string
 	ObjectLockMode            string
 	ObjectLockRetainUntilDate time.Time
+	Progress                  ProgressListener
+	ProgressInterval          time.Duration
 	RequestPayer              string
 	SSECustomerAlgorithm      string
 	SSECustomerKey            string
@@ -55,10 +79,12 @@ type UploadInput struct {
 	ServerSideEncryption      string
 	StorageClass              string
 	Tagging                   string
+	VerifyIntegrity           bool
 	WebsiteRedirectLocation   string
 }
 
 type DownloadInput struct {
+	BandwidthLimit             int64
 	Bucket                     string
 	IfMatch                    string
 	IfModifiedSince            time.Time
@@ -66,6 +92,9 @@ type DownloadInput struct {
 	IfUnmodifiedSince          time.Time
 	FileName                   string
 	PartNumber                 *int64
+	PreserveLastModified       bool
+	Progress                   ProgressListener
+	ProgressInterval           time.Duration
 	Range                      string
 	RequestPayer               string
 	ResponseCacheControl       string
@@ -77,19 +106,79 @@ type DownloadInput struct {
 	SSECustomerAlgorithm       string
 	SSECustomerKey             string
 	SSECustomerKeyMD5          string
+	VerifyIntegrity            bool
 	VersionId                  string
 }
 
 type Options struct {
-	AccessKeyId     string `envconfig:"AWS_ACCESS_KEY_ID" required:"true"`
-	SecretAccessKey string `envconfig:"AWS_SECRET_ACCESS_KEY" required:"true"`
-	Region          string `envconfig:"AWS_REGION" default:"eu-west-1"`
-	Bucket          string `envconfig:"AWS_BUCKET" required:"true"`
-	Token           string `envconfig:"AWS_TOKEN" default:""`
+	ConfigFile string `envconfig:"AWS_MANAGER_CONFIG_FILE" yaml:"-" json:"config_file,omitempty"`
+
+	AccessKeyId     string `envconfig:"AWS_ACCESS_KEY_ID" yaml:"access_key_id" json:"access_key_id,omitempty"`
+	SecretAccessKey string `envconfig:"AWS_SECRET_ACCESS_KEY" yaml:"secret_access_key" json:"secret_access_key,omitempty"`
+	Region          string `envconfig:"AWS_REGION" yaml:"region" json:"region,omitempty"`
+	Bucket          string `envconfig:"AWS_BUCKET" yaml:"bucket" json:"bucket,omitempty"`
+	Token           string `envconfig:"AWS_TOKEN" yaml:"token" json:"token,omitempty"`
+
+	Endpoint         string `envconfig:"AWS_ENDPOINT" yaml:"endpoint" json:"endpoint,omitempty"`
+	S3ForcePathStyle *bool  `envconfig:"AWS_S3_FORCE_PATH_STYLE" yaml:"s3_force_path_style" json:"s3_force_path_style,omitempty"`
+	DisableSSL       *bool  `envconfig:"AWS_DISABLE_SSL" yaml:"disable_ssl" json:"disable_ssl,omitempty"`
+	UseAccelerate    *bool  `envconfig:"AWS_S3_USE_ACCELERATE" yaml:"use_accelerate" json:"use_accelerate,omitempty"`
+	UseDualStack     *bool  `envconfig:"AWS_USE_DUALSTACK" yaml:"use_dualstack" json:"use_dualstack,omitempty"`
+
+	MaxRetries       *int            `envconfig:"AWS_MAX_RETRIES" yaml:"max_retries" json:"max_retries,omitempty"`
+	RetryMinDelay    time.Duration   `envconfig:"AWS_RETRY_MIN_DELAY" yaml:"retry_min_delay" json:"retry_min_delay,omitempty"`
+	RetryMaxDelay    time.Duration   `envconfig:"AWS_RETRY_MAX_DELAY" yaml:"retry_max_delay" json:"retry_max_delay,omitempty"`
+	Retryer          request.Retryer `ignored:"true" yaml:"-" json:"-"`
+	OperationTimeout time.Duration   `envconfig:"AWS_OPERATION_TIMEOUT" yaml:"operation_timeout" json:"operation_timeout,omitempty"`
+
+	MaxIdleConns        int           `envconfig:"AWS_HTTP_MAX_IDLE_CONNS" yaml:"http_max_idle_conns" json:"http_max_idle_conns,omitempty"`
+	MaxIdleConnsPerHost int           `envconfig:"AWS_HTTP_MAX_IDLE_CONNS_PER_HOST" yaml:"http_max_idle_conns_per_host" json:"http_max_idle_conns_per_host,omitempty"`
+	MaxConnsPerHost     int           `envconfig:"AWS_HTTP_MAX_CONNS_PER_HOST" yaml:"http_max_conns_per_host" json:"http_max_conns_per_host,omitempty"`
+	IdleConnTimeout     time.Duration `envconfig:"AWS_HTTP_IDLE_CONN_TIMEOUT" yaml:"http_idle_conn_timeout" json:"http_idle_conn_timeout,omitempty"`
+	ProxyURL            string        `envconfig:"AWS_HTTP_PROXY" yaml:"proxy_url" json:"proxy_url,omitempty"`
+	CABundle            string        `envconfig:"AWS_CA_BUNDLE" yaml:"ca_bundle" json:"ca_bundle,omitempty"`
+
+	CredentialsSource     string `envconfig:"AWS_CREDENTIALS_SOURCE" yaml:"credentials_source" json:"credentials_source,omitempty"`
+	Profile               string `envconfig:"AWS_PROFILE" yaml:"profile" json:"profile,omitempty"`
+	SharedCredentialsFile string `envconfig:"AWS_SHARED_CREDENTIALS_FILE" yaml:"shared_credentials_file" json:"shared_credentials_file,omitempty"`
+	WebIdentityTokenFile  string `envconfig:"AWS_WEB_IDENTITY_TOKEN_FILE" yaml:"web_identity_token_file" json:"web_identity_token_file,omitempty"`
+	WebIdentityRoleARN    string `envconfig:"AWS_ROLE_ARN" yaml:"web_identity_role_arn" json:"web_identity_role_arn,omitempty"`
+	RoleSessionName       string `envconfig:"AWS_ROLE_SESSION_NAME" yaml:"role_session_name" json:"role_session_name,omitempty"`
+
+	AssumeRoleARN           string                 `envconfig:"AWS_ASSUME_ROLE_ARN" yaml:"assume_role_arn" json:"assume_role_arn,omitempty"`
+	AssumeRoleExternalID    string                 `envconfig:"AWS_ASSUME_ROLE_EXTERNAL_ID" yaml:"assume_role_external_id" json:"assume_role_external_id,omitempty"`
+	AssumeRoleDuration      time.Duration          `envconfig:"AWS_ASSUME_ROLE_DURATION" yaml:"assume_role_duration" json:"assume_role_duration,omitempty"`
+	AssumeRoleMFASerial     string                 `envconfig:"AWS_ASSUME_ROLE_MFA_SERIAL" yaml:"assume_role_mfa_serial" json:"assume_role_mfa_serial,omitempty"`
+	AssumeRoleTokenProvider func() (string, error) `ignored:"true" yaml:"-" json:"-"`
+	STSEndpoint             string                 `envconfig:"AWS_STS_ENDPOINT" yaml:"sts_endpoint" json:"sts_endpoint,omitempty"`
+
+	UploadPartSize      int64 `envconfig:"AWS_UPLOAD_PART_SIZE" yaml:"upload_part_size" json:"upload_part_size,omitempty"`
+	UploadConcurrency   int   `envconfig:"AWS_UPLOAD_CONCURRENCY" yaml:"upload_concurrency" json:"upload_concurrency,omitempty"`
+	UploadMaxParts      int   `envconfig:"AWS_UPLOAD_MAX_PARTS" yaml:"upload_max_parts" json:"upload_max_parts,omitempty"`
+	LeavePartsOnError   *bool `envconfig:"AWS_UPLOAD_LEAVE_PARTS_ON_ERROR" yaml:"upload_leave_parts_on_error" json:"upload_leave_parts_on_error,omitempty"`
+	DownloadPartSize    int64 `envconfig:"AWS_DOWNLOAD_PART_SIZE" yaml:"download_part_size" json:"download_part_size,omitempty"`
+	DownloadConcurrency int   `envconfig:"AWS_DOWNLOAD_CONCURRENCY" yaml:"download_concurrency" json:"download_concurrency,omitempty"`
+	BandwidthLimit      int64 `envconfig:"AWS_BANDWIDTH_LIMIT" yaml:"bandwidth_limit" json:"bandwidth_limit,omitempty"`
+	VerifyIntegrity     *bool `envconfig:"AWS_VERIFY_INTEGRITY" yaml:"verify_integrity" json:"verify_integrity,omitempty"`
+
+	AllowedContentTypes []string `envconfig:"AWS_ALLOWED_CONTENT_TYPES" yaml:"allowed_content_types" json:"allowed_content_types,omitempty"`
+
+	UploadBufferProvider s3manager.ReadSeekerWriteToProvider `ignored:"true" yaml:"-" json:"-"`
+	UploaderOptions      []func(*s3manager.Uploader)         `ignored:"true" yaml:"-" json:"-"`
+	DownloaderOptions    []func(*s3manager.Downloader)       `ignored:"true" yaml:"-" json:"-"`
+
+	Defaults *UploadDefaults `ignored:"true" yaml:"defaults" json:"defaults,omitempty"`
 }
 
 type Option func(*Options)
 
+// ConfigFile sets the YAML or JSON file the options are loaded from, overriding AWS_MANAGER_CONFIG_FILE.
+func ConfigFile(path string) Option {
+	return func(opts *Options) {
+		opts.ConfigFile = path
+	}
+}
+
 func AccessKeyId(accessKeyId string) Option {
 	return func(opts *Options) {
 		opts.AccessKeyId = accessKeyId
@@ -120,61 +209,524 @@ func Token(token string) Option {
 	}
 }
 
+// Endpoint sets the URL of an S3 compatible store, e.g. MinIO or LocalStack, used instead of AWS S3.
+func Endpoint(endpoint string) Option {
+	return func(opts *Options) {
+		opts.Endpoint = endpoint
+	}
+}
+
+// S3ForcePathStyle addresses buckets in the URL path instead of the host name.
+func S3ForcePathStyle(force bool) Option {
+	return func(opts *Options) {
+		opts.S3ForcePathStyle = &force
+	}
+}
+
+func DisableSSL(disable bool) Option {
+	return func(opts *Options) {
+		opts.DisableSSL = &disable
+	}
+}
+
+func UseAccelerate(accelerate bool) Option {
+	return func(opts *Options) {
+		opts.UseAccelerate = &accelerate
+	}
+}
+
+func UseDualStack(dualStack bool) Option {
+	return func(opts *Options) {
+		opts.UseDualStack = &dualStack
+	}
+}
+
+func MaxRetries(maxRetries int) Option {
+	return func(opts *Options) {
+		opts.MaxRetries = &maxRetries
+	}
+}
+
+// RetryDelay sets the bounds of the exponential backoff between retries.
+func RetryDelay(minDelay, maxDelay time.Duration) Option {
+	return func(opts *Options) {
+		opts.RetryMinDelay = minDelay
+		opts.RetryMaxDelay = maxDelay
+	}
+}
+
+// Retryer replaces the retryer built from the retry options.
+func Retryer(retryer request.Retryer) Option {
+	return func(opts *Options) {
+		opts.Retryer = retryer
+	}
+}
+
+// OperationTimeout limits the duration of each S3 API call including its retries.
+func OperationTimeout(timeout time.Duration) Option {
+	return func(opts *Options) {
+		opts.OperationTimeout = timeout
+	}
+}
+
+func MaxIdleConns(maxIdleConns int) Option {
+	return func(opts *Options) {
+		opts.MaxIdleConns = maxIdleConns
+	}
+}
+
+func MaxIdleConnsPerHost(maxIdleConns int) Option {
+	return func(opts *Options) {
+		opts.MaxIdleConnsPerHost = maxIdleConns
+	}
+}
+
+func MaxConnsPerHost(maxConns int) Option {
+	return func(opts *Options) {
+		opts.MaxConnsPerHost = maxConns
+	}
+}
+
+func IdleConnTimeout(timeout time.Duration) Option {
+	return func(opts *Options) {
+		opts.IdleConnTimeout = timeout
+	}
+}
+
+func ProxyURL(proxyURL string) Option {
+	return func(opts *Options) {
+		opts.ProxyURL = proxyURL
+	}
+}
+
+// CABundle sets the path of a PEM file with the certificate authorities trusted instead of the system ones.
+func CABundle(path string) Option {
+	return func(opts *Options) {
+		opts.CABundle = path
+	}
+}
+
+// CredentialsSource selects a single source of credentials: static, env, shared, ec2, ecs or web_identity.
+// By default all of them are tried in turn.
+func CredentialsSource(source string) Option {
+	return func(opts *Options) {
+		opts.CredentialsSource = source
+	}
+}
+
+func Profile(profile string) Option {
+	return func(opts *Options) {
+		opts.Profile = profile
+	}
+}
+
+func SharedCredentialsFile(path string) Option {
+	return func(opts *Options) {
+		opts.SharedCredentialsFile = path
+	}
+}
+
+func WebIdentityTokenFile(path string) Option {
+	return func(opts *Options) {
+		opts.WebIdentityTokenFile = path
+	}
+}
+
+func WebIdentityRoleARN(arn string) Option {
+	return func(opts *Options) {
+		opts.WebIdentityRoleARN = arn
+	}
+}
+
+func RoleSessionName(name string) Option {
+	return func(opts *Options) {
+		opts.RoleSessionName = name
+	}
+}
+
+// AssumeRole makes the manager assume the role with the resolved credentials
+// and use the temporary credentials of the role, which are refreshed before they expire.
+func AssumeRole(arn string) Option {
+	return func(opts *Options) {
+		opts.AssumeRoleARN = arn
+	}
+}
+
+func AssumeRoleExternalID(externalID string) Option {
+	return func(opts *Options) {
+		opts.AssumeRoleExternalID = externalID
+	}
+}
+
+func AssumeRoleDuration(duration time.Duration) Option {
+	return func(opts *Options) {
+		opts.AssumeRoleDuration = duration
+	}
+}
+
+// AssumeRoleMFA sets the MFA device serial number and the provider of its token codes,
+// called each time the role credentials are refreshed.
+func AssumeRoleMFA(serial string, tokenProvider func() (string, error)) Option {
+	return func(opts *Options) {
+		opts.AssumeRoleMFASerial = serial
+		opts.AssumeRoleTokenProvider = tokenProvider
+	}
+}
+
+func STSEndpoint(endpoint string) Option {
+	return func(opts *Options) {
+		opts.STSEndpoint = endpoint
+	}
+}
+
+func UploadPartSize(size int64) Option {
+	return func(opts *Options) {
+		opts.UploadPartSize = size
+	}
+}
+
+func UploadConcurrency(concurrency int) Option {
+	return func(opts *Options) {
+		opts.UploadConcurrency = concurrency
+	}
+}
+
+// UploadBufferProvider sets how the uploader buffers the parts of seekable bodies, e.g. files, while sending them.
+// s3manager.NewBufferedReadSeekerWriteToPool reuses the buffers, nil keeps the SDK default.
+func UploadBufferProvider(provider s3manager.ReadSeekerWriteToProvider) Option {
+	return func(opts *Options) {
+		opts.UploadBufferProvider = provider
+	}
+}
+
+func UploadMaxParts(maxParts int) Option {
+	return func(opts *Options) {
+		opts.UploadMaxParts = maxParts
+	}
+}
+
+func LeavePartsOnError(leave bool) Option {
+	return func(opts *Options) {
+		opts.LeavePartsOnError = &leave
+	}
+}
+
+func DownloadPartSize(size int64) Option {
+	return func(opts *Options) {
+		opts.DownloadPartSize = size
+	}
+}
+
+func DownloadConcurrency(concurrency int) Option {
+	return func(opts *Options) {
+		opts.DownloadConcurrency = concurrency
+	}
+}
+
+// BandwidthLimit limits the bytes per second of the upload bodies and download writers,
+// shared by all concurrent transfers of the manager.
+func BandwidthLimit(bytesPerSecond int64) Option {
+	return func(opts *Options) {
+		opts.BandwidthLimit = bytesPerSecond
+	}
+}
+
+// VerifyIntegrity enables the integrity mode for every transfer, VerifyIntegrity of the inputs enables it
+// for a single call. Uploads send the Content-MD5 of every part and store the SHA-256 of seekable bodies
+// in the metadata, Download compares the received file with it and removes the file on ErrChecksumMismatch.
+func VerifyIntegrity(verify bool) Option {
+	return func(opts *Options) {
+		opts.VerifyIntegrity = &verify
+	}
+}
+
+// AllowedContentTypes limits uploads to the content types matching one of the patterns, e.g. "image/*".
+// Uploads whose leading bytes disagree with the declared content type, or the type of the file
+// extension when none is declared, are rejected as well.
+func AllowedContentTypes(patterns ...string) Option {
+	return func(opts *Options) {
+		opts.AllowedContentTypes = patterns
+	}
+}
+
+// UploaderOptions sets uploader options applied to every upload, e.g. a custom buffer strategy.
+func UploaderOptions(options ...func(*s3manager.Uploader)) Option {
+	return func(opts *Options) {
+		opts.UploaderOptions = append(opts.UploaderOptions, options...)
+	}
+}
+
+// DownloaderOptions sets downloader options applied to every download.
+func DownloaderOptions(options ...func(*s3manager.Downloader)) Option {
+	return func(opts *Options) {
+		opts.DownloaderOptions = append(opts.DownloaderOptions, options...)
+	}
+}
+
+// Defaults sets the fields applied to each UploadInput of the manager which leaves them empty.
+func Defaults(defaults UploadDefaults) Option {
+	return func(opts *Options) {
+		opts.Defaults = &defaults
+	}
+}
+
 func New(options ...Option) (AwsManagerInterface, error) {
-	opts := Options{}
-	conn := &Options{}
+	conn, err := loadOptions(options...)
 
-	for _, opt := range options {
-		opt(&opts)
+	if err != nil {
+		return nil, err
 	}
 
-	if opts.HasEmptySettings() {
-		err := envconfig.Process("", conn)
+	sess, err := newSession(conn)
 
-		if err != nil {
-			return nil, err
-		}
+	if err != nil {
+		return nil, err
+	}
+
+	manager, err := newManager(sess, conn)
+
+	if err != nil {
+		return nil, err
 	}
 
-	if opts.AccessKeyId != "" {
-		conn.AccessKeyId = opts.AccessKeyId
+	return manager, nil
+}
+
+// merge overrides the settings of opts by the non zero settings of src.
+func (opts *Options) merge(src *Options) {
+	if src.AccessKeyId != "" {
+		opts.AccessKeyId = src.AccessKeyId
 	}
 
-	if opts.SecretAccessKey != "" {
-		conn.SecretAccessKey = opts.SecretAccessKey
+	if src.SecretAccessKey != "" {
+		opts.SecretAccessKey = src.SecretAccessKey
 	}
 
-	if opts.Region != "" {
-		conn.Region = opts.Region
+	if src.Region != "" {
+		opts.Region = src.Region
 	}
 
-	if opts.Bucket != "" {
-		conn.Bucket = opts.Bucket
+	if src.Bucket != "" {
+		opts.Bucket = src.Bucket
 	}
 
-	if opts.Token != "" {
-		conn.Token = opts.Token
+	if src.Token != "" {
+		opts.Token = src.Token
 	}
 
-	sess, err := session.NewSession(
-		&aws.Config{
-			Region: aws.String(conn.Region),
-			Credentials: credentials.NewStaticCredentials(
-				conn.AccessKeyId,
-				conn.SecretAccessKey,
-				conn.Token,
-			),
-		},
-	)
+	if src.Endpoint != "" {
+		opts.Endpoint = src.Endpoint
+	}
+
+	if src.S3ForcePathStyle != nil {
+		opts.S3ForcePathStyle = src.S3ForcePathStyle
+	}
+
+	if src.DisableSSL != nil {
+		opts.DisableSSL = src.DisableSSL
+	}
+
+	if src.UseAccelerate != nil {
+		opts.UseAccelerate = src.UseAccelerate
+	}
+
+	if src.UseDualStack != nil {
+		opts.UseDualStack = src.UseDualStack
+	}
+
+	if src.MaxRetries != nil {
+		opts.MaxRetries = src.MaxRetries
+	}
+
+	if src.RetryMinDelay > 0 {
+		opts.RetryMinDelay = src.RetryMinDelay
+	}
+
+	if src.RetryMaxDelay > 0 {
+		opts.RetryMaxDelay = src.RetryMaxDelay
+	}
+
+	if src.Retryer != nil {
+		opts.Retryer = src.Retryer
+	}
+
+	if src.OperationTimeout > 0 {
+		opts.OperationTimeout = src.OperationTimeout
+	}
+
+	if src.MaxIdleConns > 0 {
+		opts.MaxIdleConns = src.MaxIdleConns
+	}
+
+	if src.MaxIdleConnsPerHost > 0 {
+		opts.MaxIdleConnsPerHost = src.MaxIdleConnsPerHost
+	}
+
+	if src.MaxConnsPerHost > 0 {
+		opts.MaxConnsPerHost = src.MaxConnsPerHost
+	}
+
+	if src.IdleConnTimeout > 0 {
+		opts.IdleConnTimeout = src.IdleConnTimeout
+	}
+
+	if src.ProxyURL != "" {
+		opts.ProxyURL = src.ProxyURL
+	}
+
+	if src.CABundle != "" {
+		opts.CABundle = src.CABundle
+	}
+
+	if src.CredentialsSource != "" {
+		opts.CredentialsSource = src.CredentialsSource
+	}
+
+	if src.Profile != "" {
+		opts.Profile = src.Profile
+	}
+
+	if src.SharedCredentialsFile != "" {
+		opts.SharedCredentialsFile = src.SharedCredentialsFile
+	}
+
+	if src.WebIdentityTokenFile != "" {
+		opts.WebIdentityTokenFile = src.WebIdentityTokenFile
+	}
+
+	if src.WebIdentityRoleARN != "" {
+		opts.WebIdentityRoleARN = src.WebIdentityRoleARN
+	}
+
+	if src.RoleSessionName != "" {
+		opts.RoleSessionName = src.RoleSessionName
+	}
+
+	if src.AssumeRoleARN != "" {
+		opts.AssumeRoleARN = src.AssumeRoleARN
+	}
+
+	if src.AssumeRoleExternalID != "" {
+		opts.AssumeRoleExternalID = src.AssumeRoleExternalID
+	}
+
+	if src.AssumeRoleDuration > 0 {
+		opts.AssumeRoleDuration = src.AssumeRoleDuration
+	}
+
+	if src.AssumeRoleMFASerial != "" {
+		opts.AssumeRoleMFASerial = src.AssumeRoleMFASerial
+	}
+
+	if src.AssumeRoleTokenProvider != nil {
+		opts.AssumeRoleTokenProvider = src.AssumeRoleTokenProvider
+	}
+
+	if src.STSEndpoint != "" {
+		opts.STSEndpoint = src.STSEndpoint
+	}
+
+	if src.UploadPartSize > 0 {
+		opts.UploadPartSize = src.UploadPartSize
+	}
+
+	if src.UploadConcurrency > 0 {
+		opts.UploadConcurrency = src.UploadConcurrency
+	}
+
+	if src.UploadMaxParts > 0 {
+		opts.UploadMaxParts = src.UploadMaxParts
+	}
+
+	if src.UploadBufferProvider != nil {
+		opts.UploadBufferProvider = src.UploadBufferProvider
+	}
+
+	if src.LeavePartsOnError != nil {
+		opts.LeavePartsOnError = src.LeavePartsOnError
+	}
+
+	if src.DownloadPartSize > 0 {
+		opts.DownloadPartSize = src.DownloadPartSize
+	}
+
+	if src.DownloadConcurrency > 0 {
+		opts.DownloadConcurrency = src.DownloadConcurrency
+	}
+
+	if src.BandwidthLimit > 0 {
+		opts.BandwidthLimit = src.BandwidthLimit
+	}
+
+	if src.VerifyIntegrity != nil {
+		opts.VerifyIntegrity = src.VerifyIntegrity
+	}
+
+	if len(src.AllowedContentTypes) > 0 {
+		opts.AllowedContentTypes = src.AllowedContentTypes
+	}
+
+	if len(src.UploaderOptions) > 0 {
+		opts.UploaderOptions = src.UploaderOptions
+	}
+
+	if len(src.DownloaderOptions) > 0 {
+		opts.DownloaderOptions = src.DownloaderOptions
+	}
+
+	if src.Defaults != nil {
+		opts.Defaults = src.Defaults
+	}
+}
+
+// newSession returns the session with the region, retry and transport settings of opts.
+func newSession(opts *Options) (*session.Session, error) {
+	cfg, err := opts.sessionConfig()
 
 	if err != nil {
 		return nil, err
 	}
 
+	sessOpts := session.Options{Config: *cfg}
+
+	if opts.CABundle != "" {
+		bundle, err := os.Open(opts.CABundle)
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer bundle.Close()
+		sessOpts.CustomCABundle = bundle
+	}
+
+	return session.NewSessionWithOptions(sessOpts)
+}
+
+// newManager returns the manager of the bucket of conn. The session may be shared with other managers
+// of the same region, credentials and client settings of conn apply to this manager only.
+func newManager(sess *session.Session, conn *Options) (*AwsManager, error) {
+	creds, err := newCredentials(sess, conn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := conn.s3Config()
+	cfg.Credentials = creds
+	client := s3.New(sess, cfg)
+
+	if conn.OperationTimeout > 0 {
+		withOperationTimeout(client, conn.OperationTimeout)
+	}
+
 	manager := &AwsManager{
 		cfg:           conn,
-		awsUploader:   s3manager.NewUploader(sess),
-		awsDownloader: s3manager.NewDownloader(sess),
+		credentials:   creds,
+		awsS3:         client,
+		awsUploader:   s3manager.NewUploaderWithClient(client),
+		awsDownloader: s3manager.NewDownloaderWithClient(client),
+		limiter:       newRateLimiter(conn.BandwidthLimit),
 	}
 
 	return manager, nil
@@ -200,8 +752,48 @@ func (m *AwsManager) Upload(
 		in.Bucket = m.cfg.Bucket
 	}
 
+	m.cfg.Defaults.apply(in)
+
+	if err := m.detectContentType(in); err != nil {
+		return nil, err
+	}
+
 	s3In := in.toAwsUploadInput()
-	return m.awsUploader.UploadWithContext(ctx, s3In)
+	var progress *progressTracker
+
+	if in.VerifyIntegrity || aws.BoolValue(m.cfg.VerifyIntegrity) {
+		if err := addIntegrityMetadata(s3In); err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, integrityUploaderOption)
+	}
+
+	size := readerSize(s3In.Body)
+
+	if in.Progress != nil {
+		progress = newProgressTracker(in.Progress, in.ProgressInterval, size)
+		s3In.Body = progress.reader(s3In.Body)
+		opts = append(opts, progress.uploaderOption)
+	}
+
+	limiters := m.transferLimiters(in.BandwidthLimit)
+	s3In.Body = throttleReader(ctx, s3In.Body, limiters)
+
+	// The wrapped body hides Seek, so the uploader can't fit the part size to MaxUploadParts itself.
+	if (progress != nil || len(limiters) > 0) && size > 0 {
+		opts = append(opts, fitPartSize(size))
+	}
+
+	out, err := m.awsUploader.UploadWithContext(ctx, s3In, m.uploaderOptions(opts))
+
+	if err != nil {
+		return nil, wrapError(err, "Upload", in.Bucket, in.FileName, in.SSECustomerKey != "")
+	}
+
+	progress.finish()
+
+	return out, nil
 }
 
 func (m *AwsManager) Download(
@@ -209,21 +801,72 @@ func (m *AwsManager) Download(
 	path string,
 	in *DownloadInput,
 	opts ...func(*s3manager.Downloader),
-) (int64, error) {
-	file, err := os.Create(path)
+) (*DownloadOutput, error) {
+	return m.downloadFile(ctx, path, in, opts...)
+}
 
-	if err != nil {
-		return 0, err
+// fitPartSize raises the part size so a body of size bytes fits into MaxUploadParts, in the same way
+// as the uploader does for seekable bodies. It must be the last option as it depends on the final settings.
+func fitPartSize(size int64) func(*s3manager.Uploader) {
+	return func(u *s3manager.Uploader) {
+		if u.MaxUploadParts > 0 && size/u.PartSize >= int64(u.MaxUploadParts) {
+			u.PartSize = size/int64(u.MaxUploadParts) + 1
+		}
 	}
+}
 
-	defer file.Close()
+// uploaderOptions combines the manager tuning with the per-call options, which take precedence.
+func (m *AwsManager) uploaderOptions(opts []func(*s3manager.Uploader)) func(*s3manager.Uploader) {
+	return func(u *s3manager.Uploader) {
+		if m.cfg.UploadPartSize > 0 {
+			u.PartSize = m.cfg.UploadPartSize
+		}
 
-	if in.Bucket == "" {
-		in.Bucket = m.cfg.Bucket
+		if m.cfg.UploadConcurrency > 0 {
+			u.Concurrency = m.cfg.UploadConcurrency
+		}
+
+		if m.cfg.UploadMaxParts > 0 {
+			u.MaxUploadParts = m.cfg.UploadMaxParts
+		}
+
+		if m.cfg.UploadBufferProvider != nil {
+			u.BufferProvider = m.cfg.UploadBufferProvider
+		}
+
+		if m.cfg.LeavePartsOnError != nil {
+			u.LeavePartsOnError = *m.cfg.LeavePartsOnError
+		}
+
+		for _, opt := range m.cfg.UploaderOptions {
+			opt(u)
+		}
+
+		for _, opt := range opts {
+			opt(u)
+		}
 	}
+}
+
+// downloaderOptions combines the manager tuning with the per-call options, which take precedence.
+func (m *AwsManager) downloaderOptions(opts []func(*s3manager.Downloader)) func(*s3manager.Downloader) {
+	return func(d *s3manager.Downloader) {
+		if m.cfg.DownloadPartSize > 0 {
+			d.PartSize = m.cfg.DownloadPartSize
+		}
 
-	s3In := in.toAwsGetObjectInput()
-	return m.awsDownloader.DownloadWithContext(ctx, file, s3In)
+		if m.cfg.DownloadConcurrency > 0 {
+			d.Concurrency = m.cfg.DownloadConcurrency
+		}
+
+		for _, opt := range m.cfg.DownloaderOptions {
+			opt(d)
+		}
+
+		for _, opt := range opts {
+			opt(d)
+		}
+	}
 }
 
 func (m *UploadInput) toAwsUploadInput() *s3manager.UploadInput {
@@ -428,6 +1071,94 @@ func (m *DownloadInput) toAwsGetObjectInput() *s3.GetObjectInput {
 	return out
 }
 
+// sessionConfig returns the region, retry and HTTP transport settings shared by all clients.
+func (opts *Options) sessionConfig() (*aws.Config, error) {
+	cfg := &aws.Config{
+		Region: aws.String(opts.Region),
+	}
+
+	httpClient, err := newHTTPClient(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if httpClient != nil {
+		cfg.HTTPClient = httpClient
+	}
+
+	if opts.MaxRetries != nil {
+		cfg.MaxRetries = aws.Int(*opts.MaxRetries)
+	}
+
+	switch {
+	case opts.Retryer != nil:
+		cfg = request.WithRetryer(cfg, opts.Retryer)
+	case opts.MaxRetries != nil || opts.RetryMinDelay > 0 || opts.RetryMaxDelay > 0:
+		maxRetries := defaultMaxRetries
+
+		if opts.MaxRetries != nil {
+			maxRetries = *opts.MaxRetries
+		}
+
+		cfg = request.WithRetryer(cfg, NewRetryer(maxRetries, opts.RetryMinDelay, opts.RetryMaxDelay))
+	}
+
+	return cfg, nil
+}
+
+// s3Config returns the settings which apply to the S3 client only, so that STS keeps using AWS endpoints.
+func (opts *Options) s3Config() *aws.Config {
+	cfg := &aws.Config{
+		S3ForcePathStyle: aws.Bool(aws.BoolValue(opts.S3ForcePathStyle)),
+		DisableSSL:       aws.Bool(aws.BoolValue(opts.DisableSSL)),
+		S3UseAccelerate:  aws.Bool(aws.BoolValue(opts.UseAccelerate)),
+		UseDualStack:     aws.Bool(aws.BoolValue(opts.UseDualStack)),
+	}
+
+	if opts.Endpoint != "" {
+		cfg.Endpoint = aws.String(opts.Endpoint)
+	}
+
+	return cfg
+}
+
+// bucketURL returns the URL of the bucket as addressed by the S3 client.
+func (opts *Options) bucketURL(bucket string) string {
+	scheme := "https"
+	host := fmt.Sprintf("s3.%s.amazonaws.com", opts.Region)
+	accelerate, dualStack := aws.BoolValue(opts.UseAccelerate), aws.BoolValue(opts.UseDualStack)
+
+	if aws.BoolValue(opts.DisableSSL) {
+		scheme = "http"
+	}
+
+	switch {
+	case opts.Endpoint != "":
+		host = opts.Endpoint
+
+		if u, err := url.Parse(opts.Endpoint); err == nil && u.Host != "" {
+			scheme, host = u.Scheme, u.Host
+		}
+	case accelerate && dualStack:
+		host = "s3-accelerate.dualstack.amazonaws.com"
+	case accelerate:
+		host = "s3-accelerate.amazonaws.com"
+	case dualStack:
+		host = fmt.Sprintf("s3.dualstack.%s.amazonaws.com", opts.Region)
+	}
+
+	if aws.BoolValue(opts.S3ForcePathStyle) && (!accelerate || opts.Endpoint != "") {
+		return fmt.Sprintf("%s://%s/%s/", scheme, host, bucket)
+	}
+
+	return fmt.Sprintf("%s://%s.%s/", scheme, bucket, host)
+}
+
+// HasEmptySettings tells whether the options miss required settings or are invalid. Static keys are optional
+// as the credentials chain is used without them.
+//
+// Deprecated: use Validate, which reports the problems.
 func (opts *Options) HasEmptySettings() bool {
-	return opts.AccessKeyId == "" || opts.SecretAccessKey == "" || opts.Region == "" || opts.Bucket == ""
+	return opts.Validate() != nil
 }
@@ -0,0 +1,268 @@
+package aws_manager
+
+import (
+	"fmt"
+	"github.com/kelseyhightower/envconfig"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	configFileEnv = "AWS_MANAGER_CONFIG_FILE"
+
+	bucketNameMinLength = 3
+	bucketNameMaxLength = 63
+)
+
+var (
+	regionPattern     = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]{1,2}$`)
+	bucketNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]*[a-z0-9]$`)
+)
+
+// ValidationError describes a single invalid setting of Options.
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+// ValidationErrors holds all problems found by Options.Validate.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return "invalid options: " + strings.Join(msgs, "; ")
+}
+
+// Field returns the first problem of the field, or nil when the field is valid.
+func (e ValidationErrors) Field(field string) *ValidationError {
+	for _, err := range e {
+		if err.Field == field {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ConfigError is returned when the config file can't be read or parsed.
+type ConfigError struct {
+	Path string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid config file %s: %v", e.Path, e.Err)
+}
+
+// LoadOptions reads the options from a YAML or JSON file. Unknown keys are reported as errors,
+// durations are written as strings, e.g. "30s".
+func LoadOptions(path string) (*Options, error) {
+	b, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+
+	opts := &Options{}
+
+	if err = yaml.UnmarshalStrict(b, opts); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+
+	opts.ConfigFile = path
+
+	return opts, nil
+}
+
+// loadOptions merges the settings in the order of precedence: the config file,
+// the environment variables and the functional options, each overriding the non zero settings of the previous.
+// The config file is taken from the ConfigFile option or AWS_MANAGER_CONFIG_FILE. The environment is read
+// even when the options set everything, so a malformed variable fails with the name of the variable.
+func loadOptions(options ...Option) (*Options, error) {
+	opts := &Options{}
+
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	env, err := envOptions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &Options{Region: defaultRegion}
+	path := opts.ConfigFile
+
+	if path == "" {
+		path = os.Getenv(configFileEnv)
+	}
+
+	if path != "" {
+		file, err := LoadOptions(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		conn.merge(file)
+		conn.ConfigFile = path
+	}
+
+	// The env credentials source reads the keys and the token from the environment itself.
+	if credentialsSourceOf(opts, env, conn) == CredentialsSourceEnv {
+		env.AccessKeyId, env.SecretAccessKey, env.Token = "", "", ""
+	}
+
+	conn.merge(env)
+	conn.merge(opts)
+
+	if err := conn.Validate(); err != nil {
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// envOptions reads the options from the environment. A variable which can't be parsed
+// is reported as ValidationErrors naming the variable.
+func envOptions() (*Options, error) {
+	env := &Options{}
+	err := envconfig.Process("", env)
+
+	if parseErr, ok := err.(*envconfig.ParseError); ok {
+		reason := fmt.Sprintf("malformed environment variable %s, expected %s: %v", parseErr.KeyName, parseErr.TypeName, parseErr.Err)
+		return nil, ValidationErrors{{Field: parseErr.FieldName, Reason: reason}}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// credentialsSourceOf returns the credentials source of the first options setting one.
+func credentialsSourceOf(options ...*Options) string {
+	for _, opts := range options {
+		if opts.CredentialsSource != "" {
+			return opts.CredentialsSource
+		}
+	}
+
+	return ""
+}
+
+// Validate checks the options and returns ValidationErrors with all problems found, or nil.
+func (opts *Options) Validate() error {
+	var errs ValidationErrors
+
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Field: field, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	if opts.Bucket == "" {
+		add("Bucket", "is required, set it by the option, AWS_BUCKET or the config file")
+	} else if reason := bucketNameProblem(opts.Bucket); reason != "" {
+		add("Bucket", "%q %s", opts.Bucket, reason)
+	}
+
+	if opts.Region == "" {
+		add("Region", "is required")
+	} else if opts.Endpoint == "" && !regionPattern.MatchString(opts.Region) {
+		add("Region", "%q is not a region name like eu-west-1", opts.Region)
+	}
+
+	if opts.AccessKeyId != "" && opts.SecretAccessKey == "" {
+		add("SecretAccessKey", "is required with AccessKeyId")
+	}
+
+	if opts.SecretAccessKey != "" && opts.AccessKeyId == "" {
+		add("AccessKeyId", "is required with SecretAccessKey")
+	}
+
+	if opts.Token != "" && opts.AccessKeyId == "" {
+		add("Token", "requires AccessKeyId and SecretAccessKey")
+	}
+
+	switch opts.CredentialsSource {
+	case "":
+	case CredentialsSourceEnv, CredentialsSourceShared, CredentialsSourceEC2, CredentialsSourceECS:
+		if opts.AccessKeyId != "" || opts.SecretAccessKey != "" {
+			add("CredentialsSource", "%q conflicts with AccessKeyId and SecretAccessKey", opts.CredentialsSource)
+		}
+	case CredentialsSourceStatic:
+		if opts.AccessKeyId == "" || opts.SecretAccessKey == "" {
+			add("CredentialsSource", "%q requires AccessKeyId and SecretAccessKey", opts.CredentialsSource)
+		}
+	case CredentialsSourceWebIdentity:
+		if opts.WebIdentityTokenFile == "" || opts.WebIdentityRoleARN == "" {
+			add("CredentialsSource", "%q requires WebIdentityTokenFile and WebIdentityRoleARN", opts.CredentialsSource)
+		}
+	default:
+		add("CredentialsSource", "unknown credentials source %q", opts.CredentialsSource)
+	}
+
+	if opts.RetryMinDelay < 0 || opts.RetryMaxDelay < 0 {
+		add("RetryMinDelay", "retry delays must not be negative")
+	} else if opts.RetryMaxDelay > 0 && opts.RetryMinDelay > opts.RetryMaxDelay {
+		add("RetryMinDelay", "%s is greater than RetryMaxDelay %s", opts.RetryMinDelay, opts.RetryMaxDelay)
+	}
+
+	if opts.MaxRetries != nil && *opts.MaxRetries < 0 {
+		add("MaxRetries", "must not be negative")
+	}
+
+	if opts.OperationTimeout < 0 {
+		add("OperationTimeout", "must not be negative")
+	}
+
+	if opts.BandwidthLimit < 0 {
+		add("BandwidthLimit", "must not be negative")
+	}
+
+	for _, v := range opts.AllowedContentTypes {
+		if parts := strings.Split(v, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			add("AllowedContentTypes", "%q is not a content type like image/png or image/*", v)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// bucketNameProblem returns why the name breaks the S3 bucket naming rules, or an empty string.
+func bucketNameProblem(name string) string {
+	switch {
+	case len(name) < bucketNameMinLength || len(name) > bucketNameMaxLength:
+		return fmt.Sprintf("must be between %d and %d characters long", bucketNameMinLength, bucketNameMaxLength)
+	case !bucketNamePattern.MatchString(name):
+		return "must consist of lowercase letters, numbers, dots and hyphens and begin and end with a letter or number"
+	case strings.Contains(name, ".."):
+		return "must not contain two adjacent periods"
+	case net.ParseIP(name) != nil:
+		return "must not be formatted as an IP address"
+	case strings.HasPrefix(name, "xn--"):
+		return "must not start with xn--"
+	case strings.HasSuffix(name, "-s3alias"):
+		return "must not end with -s3alias"
+	}
+
+	return ""
+}
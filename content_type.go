@@ -0,0 +1,222 @@
+package aws_manager
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+const (
+	contentTypeSniffLen     = 512
+	contentTypeZip          = "application/zip"
+	contentTypeOpenXMLStart = "application/vnd.openxmlformats-officedocument."
+)
+
+// contentTypesByExtension takes precedence over the mime package, whose table depends on the system.
+var contentTypesByExtension = map[string]string{
+	".csv":  "text/csv",
+	".doc":  "application/msword",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".gif":  "image/gif",
+	".gz":   "application/gzip",
+	".htm":  "text/html",
+	".html": "text/html",
+	".jpeg": "image/jpeg",
+	".jpg":  "image/jpeg",
+	".json": "application/json",
+	".pdf":  "application/pdf",
+	".png":  "image/png",
+	".svg":  "image/svg+xml",
+	".txt":  "text/plain",
+	".webp": "image/webp",
+	".xls":  "application/vnd.ms-excel",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".xml":  "application/xml",
+	".zip":  "application/zip",
+}
+
+// signedContentTypes are recognized by http.DetectContentType from their leading bytes,
+// so content declared as one of them must be sniffed as the same type.
+var signedContentTypes = map[string]string{
+	"application/gzip":       "application/x-gzip",
+	"application/pdf":        "application/pdf",
+	"application/postscript": "application/postscript",
+	"application/x-gzip":     "application/x-gzip",
+	"application/zip":        "application/zip",
+	"image/bmp":              "image/bmp",
+	"image/gif":              "image/gif",
+	"image/jpeg":             "image/jpeg",
+	"image/png":              "image/png",
+	"image/webp":             "image/webp",
+}
+
+// detectContentType fills an empty ContentType of the upload and enforces the allowed content types
+// of the manager. The type follows from the extension of the key or the path when it agrees with
+// the leading bytes of the body, and from the bytes otherwise. The body is not consumed.
+func (m *AwsManager) detectContentType(in *UploadInput) error {
+	policy := len(m.cfg.AllowedContentTypes) > 0
+
+	if in.Body == nil || (in.ContentType != "" && !policy) {
+		return nil
+	}
+
+	head, err := peekBody(in)
+
+	if err != nil {
+		return err
+	}
+
+	sniffed := http.DetectContentType(head)
+	declared := in.ContentType
+
+	if declared == "" {
+		declared = contentTypeByName(in.FileName)
+	}
+
+	if declared == "" {
+		declared = contentTypeByName(in.Path)
+	}
+
+	agrees := len(head) == 0 || contentTypeAgrees(declared, sniffed)
+
+	if in.ContentType == "" {
+		in.ContentType = sniffed
+
+		if declared != "" && agrees {
+			in.ContentType = declared
+		}
+	}
+
+	if !policy {
+		return nil
+	}
+
+	if declared != "" && !agrees {
+		return &Error{
+			Op:     "Upload",
+			Bucket: in.Bucket,
+			Key:    in.FileName,
+			Kind:   ErrContentTypeMismatch,
+			Err:    fmt.Errorf("content is %q, not %q", mediaType(sniffed), mediaType(declared)),
+		}
+	}
+
+	if !contentTypeAllowed(m.cfg.AllowedContentTypes, in.ContentType) {
+		return &Error{
+			Op:     "Upload",
+			Bucket: in.Bucket,
+			Key:    in.FileName,
+			Kind:   ErrContentTypeNotAllowed,
+			Err:    fmt.Errorf("content type %q is not allowed", in.ContentType),
+		}
+	}
+
+	return nil
+}
+
+// peekBody returns up to the first 512 bytes of the body. A seekable body is rewound,
+// other bodies are replaced by a reader returning the peeked bytes first.
+func peekBody(in *UploadInput) ([]byte, error) {
+	head := make([]byte, contentTypeSniffLen)
+
+	if body, ok := in.Body.(io.ReadSeeker); ok {
+		start, err := body.Seek(0, io.SeekCurrent)
+
+		if err != nil {
+			return nil, err
+		}
+
+		n, err := io.ReadFull(body, head)
+
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+
+		if _, err = body.Seek(start, io.SeekStart); err != nil {
+			return nil, err
+		}
+
+		return head[:n], nil
+	}
+
+	n, err := io.ReadFull(in.Body, head)
+
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	in.Body = io.MultiReader(bytes.NewReader(head[:n]), in.Body)
+
+	return head[:n], nil
+}
+
+func contentTypeByName(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+
+	if ext == "" {
+		return ""
+	}
+
+	if v, ok := contentTypesByExtension[ext]; ok {
+		return v
+	}
+
+	return mime.TypeByExtension(ext)
+}
+
+// contentTypeAgrees tells whether the sniffed type may be the content of the declared type.
+// Types with a signature must match exactly, text types need text and Office Open XML documents
+// need a zip archive. Other types, e.g. application/octet-stream, accept any content.
+func contentTypeAgrees(declared, sniffed string) bool {
+	declared, sniffed = mediaType(declared), mediaType(sniffed)
+
+	if declared == "" || declared == sniffed {
+		return true
+	}
+
+	if signed, ok := signedContentTypes[declared]; ok {
+		return signed == sniffed
+	}
+
+	switch {
+	case strings.HasPrefix(declared, "text/") || declared == "application/json" || declared == "application/xml" ||
+		strings.HasSuffix(declared, "+xml") || strings.HasSuffix(declared, "+json"):
+		return strings.HasPrefix(sniffed, "text/")
+	case strings.HasPrefix(declared, contentTypeOpenXMLStart):
+		return sniffed == contentTypeZip
+	}
+
+	return true
+}
+
+// contentTypeAllowed matches the media type against patterns such as "application/pdf" or "image/*".
+func contentTypeAllowed(allowed []string, contentType string) bool {
+	contentType = mediaType(contentType)
+
+	for _, v := range allowed {
+		v = strings.ToLower(strings.TrimSpace(v))
+
+		if v == contentType || v == "*/*" {
+			return true
+		}
+
+		if strings.HasSuffix(v, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(v, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mediaType strips the parameters, e.g. the charset, from the content type.
+func mediaType(contentType string) string {
+	if v, _, err := mime.ParseMediaType(contentType); err == nil {
+		return v
+	}
+
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
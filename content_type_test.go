@@ -0,0 +1,109 @@
+package aws_manager
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Upload_DetectContentType() {
+	manager := suite.newStandInManager()
+
+	cases := []struct {
+		in          *UploadInput
+		contentType string
+	}{
+		{&UploadInput{Path: filePath, FileName: fileName}, "application/pdf"},
+		{&UploadInput{Path: filePath, FileName: "payouts"}, "application/pdf"},
+		{&UploadInput{Body: ioutil.NopCloser(bytes.NewReader([]byte("id,amount\n1,10\n"))), FileName: "payouts.csv"}, "text/csv"},
+		{&UploadInput{Body: bytes.NewReader(pngHeader), FileName: "logo"}, "image/png"},
+		{&UploadInput{Body: bytes.NewReader([]byte("not a pdf")), FileName: "fake.pdf"}, "text/plain; charset=utf-8"},
+		{&UploadInput{Body: bytes.NewReader([]byte("not a pdf")), FileName: "fake.pdf", ContentType: "application/pdf"}, "application/pdf"},
+		{&UploadInput{Body: bytes.NewReader(nil), FileName: "empty.pdf"}, "application/pdf"},
+	}
+
+	for _, c := range cases {
+		_, err := manager.Upload(context.TODO(), c.in)
+		assert.NoError(suite.T(), err)
+
+		object, ok := suite.s3Server.Object(standInBucket, c.in.FileName)
+		assert.True(suite.T(), ok)
+		assert.Equal(suite.T(), c.contentType, object.ContentType, c.in.FileName)
+	}
+
+	object, _ := suite.s3Server.Object(standInBucket, "payouts.csv")
+	assert.Equal(suite.T(), []byte("id,amount\n1,10\n"), object.Body)
+
+	content, err := ioutil.ReadFile(filePath)
+	assert.NoError(suite.T(), err)
+
+	object, _ = suite.s3Server.Object(standInBucket, fileName)
+	assert.Equal(suite.T(), content, object.Body)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Upload_AllowedContentTypes() {
+	manager := suite.newStandInManager(AllowedContentTypes("application/pdf", "image/*"))
+
+	_, err := manager.Upload(context.TODO(), &UploadInput{Path: filePath, FileName: fileName})
+	assert.NoError(suite.T(), err)
+
+	_, err = manager.Upload(context.TODO(), &UploadInput{Body: bytes.NewReader(pngHeader), FileName: "logo.png"})
+	assert.NoError(suite.T(), err)
+
+	_, err = manager.Upload(context.TODO(), &UploadInput{Body: bytes.NewReader([]byte("not a pdf")), FileName: "fake.pdf"})
+	assert.True(suite.T(), IsContentTypeMismatch(err))
+	assert.Equal(suite.T(), `Upload s3://stand-in-bucket/fake.pdf: content does not match the content type: content is "text/plain", not "application/pdf"`, err.Error())
+
+	_, err = manager.Upload(context.TODO(), &UploadInput{
+		Body:        bytes.NewReader([]byte("not a pdf")),
+		FileName:    "report",
+		ContentType: "application/pdf",
+	})
+	assert.True(suite.T(), IsContentTypeMismatch(err))
+
+	_, err = manager.Upload(context.TODO(), &UploadInput{Body: bytes.NewReader([]byte("id,amount")), FileName: "payouts.csv"})
+	assert.True(suite.T(), IsContentTypeNotAllowed(err))
+
+	_, err = manager.Upload(context.TODO(), &UploadInput{Body: bytes.NewReader(pngHeader), FileName: "logo.jpg"})
+	assert.True(suite.T(), IsContentTypeMismatch(err))
+
+	assert.Equal(suite.T(), []string{"logo.png", fileName}, suite.s3Server.Keys(standInBucket))
+}
+
+func (suite *AwsManagerTestSuite) TestContentTypeAgrees() {
+	cases := []struct {
+		declared string
+		sniffed  string
+		agrees   bool
+	}{
+		{"application/pdf", "application/pdf", true},
+		{"application/pdf", "text/plain; charset=utf-8", false},
+		{"application/pdf", "application/octet-stream", false},
+		{"application/gzip", "application/x-gzip", true},
+		{"text/csv", "text/plain; charset=utf-8", true},
+		{"application/json", "text/plain; charset=utf-8", true},
+		{"application/json", "application/octet-stream", false},
+		{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "application/zip", true},
+		{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "text/plain; charset=utf-8", false},
+		{"application/octet-stream", "image/png", true},
+		{"", "image/png", true},
+	}
+
+	for _, c := range cases {
+		assert.Equal(suite.T(), c.agrees, contentTypeAgrees(c.declared, c.sniffed), c.declared+" "+c.sniffed)
+	}
+
+	assert.True(suite.T(), contentTypeAllowed([]string{"Image/*"}, "image/png"))
+	assert.True(suite.T(), contentTypeAllowed([]string{"text/csv"}, "text/csv; charset=utf-8"))
+	assert.False(suite.T(), contentTypeAllowed([]string{"image/*"}, "imagex/png"))
+}
+
+func (suite *AwsManagerTestSuite) TestOptions_Validate_AllowedContentTypes() {
+	opts := &Options{Bucket: "bucket", Region: "eu-west-1", AllowedContentTypes: []string{"image/*", "pdf"}}
+	err := opts.Validate()
+	assert.Error(suite.T(), err)
+	assert.NotNil(suite.T(), err.(ValidationErrors).Field("AllowedContentTypes"))
+}
@@ -0,0 +1,611 @@
+package aws_manager
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	maxCopyObjectSize    = int64(5 * 1024 * 1024 * 1024)
+	minCopyPartSize      = int64(5 * 1024 * 1024)
+	defaultCopyPartSize  = int64(512 * 1024 * 1024)
+	maxCopyParts         = int64(10000)
+	copyPartsConcurrency = 5
+	requestPayerHeader   = "X-Amz-Request-Payer"
+)
+
+type CopyInput struct {
+	ACL                            string
+	Bucket                         string
+	CacheControl                   string
+	ContentDisposition             string
+	ContentEncoding                string
+	ContentLanguage                string
+	ContentType                    string
+	CopySourceIfMatch              string
+	CopySourceIfModifiedSince      time.Time
+	CopySourceIfNoneMatch          string
+	CopySourceIfUnmodifiedSince    time.Time
+	CopySourceSSECustomerAlgorithm string
+	CopySourceSSECustomerKey       string
+	CopySourceSSECustomerKeyMD5    string
+	Expires                        time.Time
+	FileName                       string
+	GrantFullControl               string
+	GrantRead                      string
+	GrantReadACP                   string
+	GrantWriteACP                  string
+	Metadata                       map[string]string
+	MetadataDirective              string
+	ObjectLockLegalHoldStatus      string
+	ObjectLockMode                 string
+	ObjectLockRetainUntilDate      time.Time
+	PartSize                       int64
+	RequestPayer                   string
+	SSECustomerAlgorithm           string
+	SSECustomerKey                 string
+	SSECustomerKeyMD5              string
+	SSEKMSEncryptionContext        string
+	SSEKMSKeyId                    string
+	ServerSideEncryption           string
+	SourceBucket                   string
+	SourceFileName                 string
+	SourceVersionId                string
+	StorageClass                   string
+	Tagging                        string
+	TaggingDirective               string
+	WebsiteRedirectLocation        string
+}
+
+type CopyOutput struct {
+	ETag                 string
+	LastModified         time.Time
+	Multipart            bool
+	SSEKMSKeyId          string
+	ServerSideEncryption string
+	SourceVersionId      string
+	VersionId            string
+}
+
+// Copy copies the object on the server side. Objects larger than 5 GB, which
+// CopyObject does not accept, are copied part by part with UploadPartCopy.
+func (m *AwsManager) Copy(ctx context.Context, in *CopyInput) (*CopyOutput, error) {
+	if in.SourceBucket == "" {
+		in.SourceBucket = m.cfg.Bucket
+	}
+
+	if in.Bucket == "" {
+		in.Bucket = m.cfg.Bucket
+	}
+
+	statIn := &StatInput{
+		Bucket:               in.SourceBucket,
+		FileName:             in.SourceFileName,
+		RequestPayer:         in.RequestPayer,
+		SSECustomerAlgorithm: in.CopySourceSSECustomerAlgorithm,
+		SSECustomerKey:       in.CopySourceSSECustomerKey,
+		SSECustomerKeyMD5:    in.CopySourceSSECustomerKeyMD5,
+		VersionId:            in.SourceVersionId,
+	}
+	source, err := m.Stat(ctx, statIn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sseCustomerKey := in.SSECustomerKey != "" || in.CopySourceSSECustomerKey != ""
+
+	if source.Size > maxCopyObjectSize {
+		out, err := m.copyMultipart(ctx, in, source)
+
+		if err != nil {
+			return nil, wrapError(err, "Copy", in.Bucket, in.FileName, sseCustomerKey)
+		}
+
+		return out, nil
+	}
+
+	s3Out, err := m.awsS3.CopyObjectWithContext(ctx, in.toAwsCopyObjectInput())
+
+	if err != nil {
+		return nil, wrapError(err, "Copy", in.Bucket, in.FileName, sseCustomerKey)
+	}
+
+	out := &CopyOutput{
+		SSEKMSKeyId:          aws.StringValue(s3Out.SSEKMSKeyId),
+		ServerSideEncryption: aws.StringValue(s3Out.ServerSideEncryption),
+		SourceVersionId:      aws.StringValue(s3Out.CopySourceVersionId),
+		VersionId:            aws.StringValue(s3Out.VersionId),
+	}
+
+	if s3Out.CopyObjectResult != nil {
+		out.ETag = aws.StringValue(s3Out.CopyObjectResult.ETag)
+		out.LastModified = aws.TimeValue(s3Out.CopyObjectResult.LastModified)
+	}
+
+	return out, nil
+}
+
+// Move copies the object and removes the source once the copy succeeded.
+// When the source and the destination are the same object, for example to
+// rewrite its metadata in place, the source is kept.
+func (m *AwsManager) Move(ctx context.Context, in *CopyInput) (*CopyOutput, error) {
+	out, err := m.Copy(ctx, in)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if in.Bucket == in.SourceBucket && in.FileName == in.SourceFileName {
+		return out, nil
+	}
+
+	deleteIn := &DeleteInput{
+		Bucket:       in.SourceBucket,
+		FileName:     in.SourceFileName,
+		RequestPayer: in.RequestPayer,
+		VersionId:    in.SourceVersionId,
+	}
+	_, err = m.Delete(ctx, deleteIn)
+
+	if err != nil {
+		return out, err
+	}
+
+	return out, nil
+}
+
+func (m *AwsManager) copyMultipart(ctx context.Context, in *CopyInput, source *ObjectInfo) (*CopyOutput, error) {
+	createIn := &s3.CreateMultipartUploadInput{}
+	awsutil.Copy(createIn, in.toAwsCopyObjectInput())
+
+	if in.MetadataDirective != s3.MetadataDirectiveReplace {
+		createIn.CacheControl = nilIfEmpty(source.CacheControl)
+		createIn.ContentDisposition = nilIfEmpty(source.ContentDisposition)
+		createIn.ContentEncoding = nilIfEmpty(source.ContentEncoding)
+		createIn.ContentLanguage = nilIfEmpty(source.ContentLanguage)
+		createIn.ContentType = nilIfEmpty(source.ContentType)
+		createIn.Metadata = nil
+
+		if len(source.Metadata) > 0 {
+			createIn.Metadata = aws.StringMap(source.Metadata)
+		}
+	}
+
+	if in.TaggingDirective != s3.TaggingDirectiveReplace {
+		tagging, err := m.getObjectTagging(ctx, in)
+
+		if err != nil {
+			return nil, err
+		}
+
+		createIn.Tagging = nilIfEmpty(tagging)
+	}
+
+	createOut, err := m.awsS3.CreateMultipartUploadWithContext(ctx, createIn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	parts, err := m.copyParts(ctx, in, source.Size, createOut.UploadId)
+
+	if err != nil {
+		abortIn := &s3.AbortMultipartUploadInput{
+			Bucket:   createIn.Bucket,
+			Key:      createIn.Key,
+			UploadId: createOut.UploadId,
+		}
+
+		if in.RequestPayer != "" {
+			abortIn.RequestPayer = aws.String(in.RequestPayer)
+		}
+
+		_, _ = m.awsS3.AbortMultipartUploadWithContext(context.Background(), abortIn)
+		return nil, err
+	}
+
+	completeIn := &s3.CompleteMultipartUploadInput{
+		Bucket:          createIn.Bucket,
+		Key:             createIn.Key,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+		RequestPayer:    createIn.RequestPayer,
+		UploadId:        createOut.UploadId,
+	}
+	completeOut, err := m.awsS3.CompleteMultipartUploadWithContext(ctx, completeIn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out := &CopyOutput{
+		ETag:                 aws.StringValue(completeOut.ETag),
+		Multipart:            true,
+		SSEKMSKeyId:          aws.StringValue(completeOut.SSEKMSKeyId),
+		ServerSideEncryption: aws.StringValue(completeOut.ServerSideEncryption),
+		SourceVersionId:      source.VersionId,
+		VersionId:            aws.StringValue(completeOut.VersionId),
+	}
+
+	return out, nil
+}
+
+func (m *AwsManager) copyParts(
+	ctx context.Context,
+	in *CopyInput,
+	size int64,
+	uploadId *string,
+) ([]*s3.CompletedPart, error) {
+	partSize := in.copyPartSize(size)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		parts    []*s3.CompletedPart
+		firstErr error
+	)
+
+	ranges := make(chan int64)
+	copySource := in.copySource()
+
+	for i := 0; i < copyPartsConcurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for offset := range ranges {
+				partIn := in.toAwsUploadPartCopyInput(copySource, uploadId, offset, size, partSize)
+				partOut, err := m.awsS3.UploadPartCopyWithContext(ctx, partIn)
+
+				mu.Lock()
+
+				if err != nil && firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+
+				if err == nil {
+					part := &s3.CompletedPart{PartNumber: partIn.PartNumber}
+
+					if partOut.CopyPartResult != nil {
+						part.ETag = partOut.CopyPartResult.ETag
+					}
+
+					parts = append(parts, part)
+				}
+
+				mu.Unlock()
+			}
+		}()
+	}
+
+produce:
+	for offset := int64(0); offset < size; offset += partSize {
+		select {
+		case ranges <- offset:
+		case <-ctx.Done():
+			break produce
+		}
+	}
+
+	close(ranges)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return aws.Int64Value(parts[i].PartNumber) < aws.Int64Value(parts[j].PartNumber)
+	})
+
+	return parts, nil
+}
+
+func (m *AwsManager) getObjectTagging(ctx context.Context, in *CopyInput) (string, error) {
+	taggingIn := &s3.GetObjectTaggingInput{
+		Bucket: aws.String(in.SourceBucket),
+		Key:    aws.String(in.SourceFileName),
+	}
+
+	if in.SourceVersionId != "" {
+		taggingIn.VersionId = aws.String(in.SourceVersionId)
+	}
+
+	var opts []request.Option
+
+	// GetObjectTaggingInput of the SDK has no RequestPayer, so the header is set on the request.
+	if in.RequestPayer != "" {
+		opts = append(opts, func(r *request.Request) {
+			r.HTTPRequest.Header.Set(requestPayerHeader, in.RequestPayer)
+		})
+	}
+
+	taggingOut, err := m.awsS3.GetObjectTaggingWithContext(ctx, taggingIn, opts...)
+
+	if err != nil {
+		return "", err
+	}
+
+	tags := url.Values{}
+
+	for _, v := range taggingOut.TagSet {
+		tags.Add(aws.StringValue(v.Key), aws.StringValue(v.Value))
+	}
+
+	return tags.Encode(), nil
+}
+
+func (m *CopyInput) copySource() string {
+	source := (&url.URL{Path: m.SourceBucket + "/" + m.SourceFileName}).EscapedPath()
+
+	if m.SourceVersionId != "" {
+		source += "?versionId=" + url.QueryEscape(m.SourceVersionId)
+	}
+
+	return source
+}
+
+func (m *CopyInput) copyPartSize(size int64) int64 {
+	partSize := m.PartSize
+
+	if partSize <= 0 {
+		partSize = defaultCopyPartSize
+	}
+
+	if partSize < minCopyPartSize {
+		partSize = minCopyPartSize
+	}
+
+	if partSize > maxCopyObjectSize {
+		partSize = maxCopyObjectSize
+	}
+
+	if size/partSize >= maxCopyParts {
+		partSize = size/maxCopyParts + 1
+	}
+
+	return partSize
+}
+
+func (m *CopyInput) toAwsCopyObjectInput() *s3.CopyObjectInput {
+	out := &s3.CopyObjectInput{
+		CopySource: aws.String(m.copySource()),
+	}
+
+	if m.ACL != "" {
+		out.ACL = aws.String(m.ACL)
+	}
+
+	if m.Bucket != "" {
+		out.Bucket = aws.String(m.Bucket)
+	}
+
+	if m.CacheControl != "" {
+		out.CacheControl = aws.String(m.CacheControl)
+	}
+
+	if m.ContentDisposition != "" {
+		out.ContentDisposition = aws.String(m.ContentDisposition)
+	}
+
+	if m.ContentEncoding != "" {
+		out.ContentEncoding = aws.String(m.ContentEncoding)
+	}
+
+	if m.ContentLanguage != "" {
+		out.ContentLanguage = aws.String(m.ContentLanguage)
+	}
+
+	if m.ContentType != "" {
+		out.ContentType = aws.String(m.ContentType)
+	}
+
+	if m.CopySourceIfMatch != "" {
+		out.CopySourceIfMatch = aws.String(m.CopySourceIfMatch)
+	}
+
+	if !m.CopySourceIfModifiedSince.IsZero() {
+		out.CopySourceIfModifiedSince = aws.Time(m.CopySourceIfModifiedSince)
+	}
+
+	if m.CopySourceIfNoneMatch != "" {
+		out.CopySourceIfNoneMatch = aws.String(m.CopySourceIfNoneMatch)
+	}
+
+	if !m.CopySourceIfUnmodifiedSince.IsZero() {
+		out.CopySourceIfUnmodifiedSince = aws.Time(m.CopySourceIfUnmodifiedSince)
+	}
+
+	if m.CopySourceSSECustomerAlgorithm != "" {
+		out.CopySourceSSECustomerAlgorithm = aws.String(m.CopySourceSSECustomerAlgorithm)
+	}
+
+	if m.CopySourceSSECustomerKey != "" {
+		out.CopySourceSSECustomerKey = aws.String(m.CopySourceSSECustomerKey)
+	}
+
+	if m.CopySourceSSECustomerKeyMD5 != "" {
+		out.CopySourceSSECustomerKeyMD5 = aws.String(m.CopySourceSSECustomerKeyMD5)
+	}
+
+	if !m.Expires.IsZero() {
+		out.Expires = aws.Time(m.Expires)
+	}
+
+	if m.FileName != "" {
+		out.Key = aws.String(m.FileName)
+	}
+
+	if m.GrantFullControl != "" {
+		out.GrantFullControl = aws.String(m.GrantFullControl)
+	}
+
+	if m.GrantRead != "" {
+		out.GrantRead = aws.String(m.GrantRead)
+	}
+
+	if m.GrantReadACP != "" {
+		out.GrantReadACP = aws.String(m.GrantReadACP)
+	}
+
+	if m.GrantWriteACP != "" {
+		out.GrantWriteACP = aws.String(m.GrantWriteACP)
+	}
+
+	if len(m.Metadata) > 0 {
+		out.Metadata = aws.StringMap(m.Metadata)
+	}
+
+	if m.MetadataDirective != "" {
+		out.MetadataDirective = aws.String(m.MetadataDirective)
+	}
+
+	if m.ObjectLockLegalHoldStatus != "" {
+		out.ObjectLockLegalHoldStatus = aws.String(m.ObjectLockLegalHoldStatus)
+	}
+
+	if m.ObjectLockMode != "" {
+		out.ObjectLockMode = aws.String(m.ObjectLockMode)
+	}
+
+	if !m.ObjectLockRetainUntilDate.IsZero() {
+		out.ObjectLockRetainUntilDate = aws.Time(m.ObjectLockRetainUntilDate)
+	}
+
+	if m.RequestPayer != "" {
+		out.RequestPayer = aws.String(m.RequestPayer)
+	}
+
+	if m.SSECustomerAlgorithm != "" {
+		out.SSECustomerAlgorithm = aws.String(m.SSECustomerAlgorithm)
+	}
+
+	if m.SSECustomerKey != "" {
+		out.SSECustomerKey = aws.String(m.SSECustomerKey)
+	}
+
+	if m.SSECustomerKeyMD5 != "" {
+		out.SSECustomerKeyMD5 = aws.String(m.SSECustomerKeyMD5)
+	}
+
+	if m.SSEKMSEncryptionContext != "" {
+		out.SSEKMSEncryptionContext = aws.String(m.SSEKMSEncryptionContext)
+	}
+
+	if m.SSEKMSKeyId != "" {
+		out.SSEKMSKeyId = aws.String(m.SSEKMSKeyId)
+	}
+
+	if m.ServerSideEncryption != "" {
+		out.ServerSideEncryption = aws.String(m.ServerSideEncryption)
+	}
+
+	if m.StorageClass != "" {
+		out.StorageClass = aws.String(m.StorageClass)
+	}
+
+	if m.Tagging != "" {
+		out.Tagging = aws.String(m.Tagging)
+	}
+
+	if m.TaggingDirective != "" {
+		out.TaggingDirective = aws.String(m.TaggingDirective)
+	}
+
+	if m.WebsiteRedirectLocation != "" {
+		out.WebsiteRedirectLocation = aws.String(m.WebsiteRedirectLocation)
+	}
+
+	return out
+}
+
+func (m *CopyInput) toAwsUploadPartCopyInput(
+	copySource string,
+	uploadId *string,
+	offset, size, partSize int64,
+) *s3.UploadPartCopyInput {
+	end := offset + partSize - 1
+
+	if end >= size {
+		end = size - 1
+	}
+
+	out := &s3.UploadPartCopyInput{
+		Bucket:          aws.String(m.Bucket),
+		CopySource:      aws.String(copySource),
+		CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", offset, end)),
+		Key:             aws.String(m.FileName),
+		PartNumber:      aws.Int64(offset/partSize + 1),
+		UploadId:        uploadId,
+	}
+
+	if m.CopySourceIfMatch != "" {
+		out.CopySourceIfMatch = aws.String(m.CopySourceIfMatch)
+	}
+
+	if !m.CopySourceIfModifiedSince.IsZero() {
+		out.CopySourceIfModifiedSince = aws.Time(m.CopySourceIfModifiedSince)
+	}
+
+	if m.CopySourceIfNoneMatch != "" {
+		out.CopySourceIfNoneMatch = aws.String(m.CopySourceIfNoneMatch)
+	}
+
+	if !m.CopySourceIfUnmodifiedSince.IsZero() {
+		out.CopySourceIfUnmodifiedSince = aws.Time(m.CopySourceIfUnmodifiedSince)
+	}
+
+	if m.CopySourceSSECustomerAlgorithm != "" {
+		out.CopySourceSSECustomerAlgorithm = aws.String(m.CopySourceSSECustomerAlgorithm)
+	}
+
+	if m.CopySourceSSECustomerKey != "" {
+		out.CopySourceSSECustomerKey = aws.String(m.CopySourceSSECustomerKey)
+	}
+
+	if m.CopySourceSSECustomerKeyMD5 != "" {
+		out.CopySourceSSECustomerKeyMD5 = aws.String(m.CopySourceSSECustomerKeyMD5)
+	}
+
+	if m.RequestPayer != "" {
+		out.RequestPayer = aws.String(m.RequestPayer)
+	}
+
+	if m.SSECustomerAlgorithm != "" {
+		out.SSECustomerAlgorithm = aws.String(m.SSECustomerAlgorithm)
+	}
+
+	if m.SSECustomerKey != "" {
+		out.SSECustomerKey = aws.String(m.SSECustomerKey)
+	}
+
+	if m.SSECustomerKeyMD5 != "" {
+		out.SSECustomerKeyMD5 = aws.String(m.SSECustomerKeyMD5)
+	}
+
+	return out
+}
+
+func nilIfEmpty(v string) *string {
+	if v == "" {
+		return nil
+	}
+
+	return aws.String(v)
+}
@@ -0,0 +1,255 @@
+package aws_manager
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+)
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Copy_SingleRequest_Ok() {
+	mockS3 := &test.S3API{}
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).
+		Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(1024)}, nil)
+	mockS3.On("CopyObjectWithContext", mock.Anything, mock.Anything).
+		Return(
+			&s3.CopyObjectOutput{
+				CopyObjectResult: &s3.CopyObjectResult{ETag: aws.String(`"etag"`)},
+				VersionId:        aws.String("VersionId"),
+			},
+			nil,
+		)
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	in := &CopyInput{
+		SourceBucket:         "staging-bucket",
+		SourceFileName:       "staging/report 1.pdf",
+		SourceVersionId:      "SourceVersionId",
+		FileName:             "archive/report 1.pdf",
+		MetadataDirective:    s3.MetadataDirectiveReplace,
+		Metadata:             map[string]string{"key": "value"},
+		TaggingDirective:     s3.TaggingDirectiveReplace,
+		Tagging:              "archived=true",
+		ServerSideEncryption: "aws:kms",
+		SSEKMSKeyId:          "SSEKMSKeyId",
+		StorageClass:         "GLACIER",
+	}
+	out, err := suite.awsManager.Copy(context.TODO(), in)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), `"etag"`, out.ETag)
+	assert.Equal(suite.T(), "VersionId", out.VersionId)
+	assert.False(suite.T(), out.Multipart)
+
+	headIn := mockS3.Calls[0].Arguments.Get(1).(*s3.HeadObjectInput)
+	assert.Equal(suite.T(), "staging-bucket", aws.StringValue(headIn.Bucket))
+	assert.Equal(suite.T(), "SourceVersionId", aws.StringValue(headIn.VersionId))
+
+	s3In := mockS3.Calls[1].Arguments.Get(1).(*s3.CopyObjectInput)
+	assert.Equal(suite.T(), "staging-bucket/staging/report%201.pdf?versionId=SourceVersionId", aws.StringValue(s3In.CopySource))
+	assert.Equal(suite.T(), suite.awsManager.(*AwsManager).cfg.Bucket, aws.StringValue(s3In.Bucket))
+	assert.Equal(suite.T(), "archive/report 1.pdf", aws.StringValue(s3In.Key))
+	assert.Equal(suite.T(), s3.MetadataDirectiveReplace, aws.StringValue(s3In.MetadataDirective))
+	assert.Equal(suite.T(), "value", aws.StringValue(s3In.Metadata["key"]))
+	assert.Equal(suite.T(), s3.TaggingDirectiveReplace, aws.StringValue(s3In.TaggingDirective))
+	assert.Equal(suite.T(), "archived=true", aws.StringValue(s3In.Tagging))
+	assert.Equal(suite.T(), "aws:kms", aws.StringValue(s3In.ServerSideEncryption))
+	assert.Equal(suite.T(), "SSEKMSKeyId", aws.StringValue(s3In.SSEKMSKeyId))
+	assert.Equal(suite.T(), "GLACIER", aws.StringValue(s3In.StorageClass))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Copy_Multipart_Ok() {
+	size := maxCopyObjectSize + 1
+	mockS3 := &test.S3API{}
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).
+		Return(
+			&s3.HeadObjectOutput{
+				ContentLength: aws.Int64(size),
+				ContentType:   aws.String("application/pdf"),
+				Metadata:      map[string]*string{"Merchant": aws.String("1")},
+				VersionId:     aws.String("SourceVersionId"),
+			},
+			nil,
+		)
+	mockS3.On("GetObjectTaggingWithContext", mock.Anything, mock.Anything).
+		Return(&s3.GetObjectTaggingOutput{TagSet: []*s3.Tag{{Key: aws.String("type"), Value: aws.String("report")}}}, nil)
+	mockS3.On("CreateMultipartUploadWithContext", mock.Anything, mock.Anything).
+		Return(&s3.CreateMultipartUploadOutput{UploadId: aws.String("UploadId")}, nil)
+	mockS3.On("UploadPartCopyWithContext", mock.Anything, mock.Anything).
+		Return(
+			func(_ context.Context, in *s3.UploadPartCopyInput, _ ...request.Option) *s3.UploadPartCopyOutput {
+				return &s3.UploadPartCopyOutput{CopyPartResult: &s3.CopyPartResult{ETag: in.CopySourceRange}}
+			},
+			nil,
+		)
+	mockS3.On("CompleteMultipartUploadWithContext", mock.Anything, mock.Anything).
+		Return(&s3.CompleteMultipartUploadOutput{ETag: aws.String(`"etag-11"`), VersionId: aws.String("VersionId")}, nil)
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	in := &CopyInput{
+		SourceFileName:       "staging/report.pdf",
+		Bucket:               "archive-bucket",
+		FileName:             "archive/report.pdf",
+		SSECustomerAlgorithm: "AES256",
+		SSECustomerKey:       "SSECustomerKey",
+	}
+	out, err := suite.awsManager.Copy(context.TODO(), in)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), out.Multipart)
+	assert.Equal(suite.T(), `"etag-11"`, out.ETag)
+	assert.Equal(suite.T(), "SourceVersionId", out.SourceVersionId)
+
+	partsCount := int((size + defaultCopyPartSize - 1) / defaultCopyPartSize)
+	mockS3.AssertNumberOfCalls(suite.T(), "UploadPartCopyWithContext", partsCount)
+	mockS3.AssertNotCalled(suite.T(), "CopyObjectWithContext", mock.Anything, mock.Anything)
+	mockS3.AssertNotCalled(suite.T(), "AbortMultipartUploadWithContext", mock.Anything, mock.Anything)
+
+	for _, call := range mockS3.Calls {
+		switch in := call.Arguments.Get(1).(type) {
+		case *s3.CreateMultipartUploadInput:
+			assert.Equal(suite.T(), "archive-bucket", aws.StringValue(in.Bucket))
+			assert.Equal(suite.T(), "application/pdf", aws.StringValue(in.ContentType))
+			assert.Equal(suite.T(), "1", aws.StringValue(in.Metadata["Merchant"]))
+			assert.Equal(suite.T(), "type=report", aws.StringValue(in.Tagging))
+			assert.Equal(suite.T(), "AES256", aws.StringValue(in.SSECustomerAlgorithm))
+		case *s3.UploadPartCopyInput:
+			assert.Equal(suite.T(), "UploadId", aws.StringValue(in.UploadId))
+			assert.Equal(suite.T(), "SSECustomerKey", aws.StringValue(in.SSECustomerKey))
+			assert.Equal(suite.T(), suite.awsManager.(*AwsManager).cfg.Bucket+"/staging/report.pdf", aws.StringValue(in.CopySource))
+		case *s3.CompleteMultipartUploadInput:
+			parts := in.MultipartUpload.Parts
+			assert.Len(suite.T(), parts, partsCount)
+			assert.Equal(suite.T(), "bytes=0-536870911", aws.StringValue(parts[0].ETag))
+			assert.Equal(suite.T(), int64(partsCount), aws.Int64Value(parts[partsCount-1].PartNumber))
+			assert.Equal(suite.T(), "bytes=5368709120-5368709120", aws.StringValue(parts[partsCount-1].ETag))
+		}
+	}
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Copy_Multipart_RequestPayer() {
+	mockS3 := &test.S3API{}
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).
+		Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(maxCopyObjectSize + 1)}, nil)
+	mockS3.On("GetObjectTaggingWithContext", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("tagging failed"))
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	_, err := suite.awsManager.Copy(context.TODO(), &CopyInput{
+		SourceFileName: "staging/report.pdf",
+		FileName:       "archive/report.pdf",
+		RequestPayer:   s3.RequestPayerRequester,
+	})
+	assert.Regexp(suite.T(), "tagging failed", err.Error())
+
+	opt, ok := mockS3.Calls[1].Arguments.Get(2).(request.Option)
+	assert.True(suite.T(), ok)
+
+	r := &request.Request{HTTPRequest: &http.Request{Header: http.Header{}}}
+	opt(r)
+	assert.Equal(suite.T(), s3.RequestPayerRequester, r.HTTPRequest.Header.Get("x-amz-request-payer"))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Copy_MultipartPartError_Aborted() {
+	mockS3 := &test.S3API{}
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).
+		Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(maxCopyObjectSize * 2)}, nil)
+	mockS3.On("CreateMultipartUploadWithContext", mock.Anything, mock.Anything).
+		Return(&s3.CreateMultipartUploadOutput{UploadId: aws.String("UploadId")}, nil)
+	mockS3.On("UploadPartCopyWithContext", mock.Anything, mock.Anything).
+		Return(nil, errors.New("part copy failed"))
+	mockS3.On("AbortMultipartUploadWithContext", mock.Anything, mock.Anything).
+		Return(&s3.AbortMultipartUploadOutput{}, nil)
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	in := &CopyInput{
+		SourceFileName:    "staging/report.pdf",
+		FileName:          "archive/report.pdf",
+		MetadataDirective: s3.MetadataDirectiveReplace,
+		TaggingDirective:  s3.TaggingDirectiveReplace,
+	}
+	out, err := suite.awsManager.Copy(context.TODO(), in)
+	assert.Nil(suite.T(), out)
+	assert.Error(suite.T(), err)
+	assert.Regexp(suite.T(), "part copy failed", err.Error())
+	mockS3.AssertNumberOfCalls(suite.T(), "AbortMultipartUploadWithContext", 1)
+	mockS3.AssertNotCalled(suite.T(), "GetObjectTaggingWithContext", mock.Anything, mock.Anything)
+	mockS3.AssertNotCalled(suite.T(), "CompleteMultipartUploadWithContext", mock.Anything, mock.Anything)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Copy_SourceNotFound_Error() {
+	mockS3 := &test.S3API{}
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).
+		Return(nil, awserr.New("NotFound", "Not Found", nil))
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	_, err := suite.awsManager.Copy(context.TODO(), &CopyInput{SourceFileName: "a.pdf", FileName: "b.pdf"})
+	assert.True(suite.T(), IsNotFound(err))
+	assert.Equal(suite.T(), "Stat", err.(*Error).Op)
+	assert.Equal(suite.T(), "a.pdf", err.(*Error).Key)
+	mockS3.AssertNotCalled(suite.T(), "CopyObjectWithContext", mock.Anything, mock.Anything)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Move_Ok() {
+	mockS3 := &test.S3API{}
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).
+		Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(1024)}, nil)
+	mockS3.On("CopyObjectWithContext", mock.Anything, mock.Anything).
+		Return(&s3.CopyObjectOutput{}, nil)
+	mockS3.On("DeleteObjectWithContext", mock.Anything, mock.Anything).
+		Return(&s3.DeleteObjectOutput{}, nil)
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	in := &CopyInput{
+		SourceFileName:  "staging/report.pdf",
+		SourceVersionId: "SourceVersionId",
+		FileName:        "archive/report.pdf",
+	}
+	_, err := suite.awsManager.Move(context.TODO(), in)
+	assert.NoError(suite.T(), err)
+
+	deleteIn := mockS3.Calls[2].Arguments.Get(1).(*s3.DeleteObjectInput)
+	assert.Equal(suite.T(), suite.awsManager.(*AwsManager).cfg.Bucket, aws.StringValue(deleteIn.Bucket))
+	assert.Equal(suite.T(), "staging/report.pdf", aws.StringValue(deleteIn.Key))
+	assert.Equal(suite.T(), "SourceVersionId", aws.StringValue(deleteIn.VersionId))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Move_SameObject_SourceKept() {
+	mockS3 := &test.S3API{}
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).
+		Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(1024)}, nil)
+	mockS3.On("CopyObjectWithContext", mock.Anything, mock.Anything).
+		Return(&s3.CopyObjectOutput{}, nil)
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	in := &CopyInput{
+		SourceFileName:    "report.pdf",
+		FileName:          "report.pdf",
+		MetadataDirective: s3.MetadataDirectiveReplace,
+		Metadata:          map[string]string{"key": "value"},
+	}
+	_, err := suite.awsManager.Move(context.TODO(), in)
+	assert.NoError(suite.T(), err)
+	mockS3.AssertNotCalled(suite.T(), "DeleteObjectWithContext", mock.Anything, mock.Anything)
+
+	exists, err := suite.awsManager.Exists(context.TODO(), "report.pdf")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), exists)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Move_CopyError_SourceKept() {
+	mockS3 := &test.S3API{}
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).
+		Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(1024)}, nil)
+	mockS3.On("CopyObjectWithContext", mock.Anything, mock.Anything).
+		Return(nil, errors.New("copy failed"))
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	_, err := suite.awsManager.Move(context.TODO(), &CopyInput{SourceFileName: "a.pdf", FileName: "b.pdf"})
+	assert.Error(suite.T(), err)
+	mockS3.AssertNotCalled(suite.T(), "DeleteObjectWithContext", mock.Anything, mock.Anything)
+}
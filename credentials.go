@@ -0,0 +1,190 @@
+package aws_manager
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	CredentialsSourceStatic      = "static"
+	CredentialsSourceEnv         = "env"
+	CredentialsSourceShared      = "shared"
+	CredentialsSourceEC2         = "ec2"
+	CredentialsSourceECS         = "ecs"
+	CredentialsSourceWebIdentity = "web_identity"
+
+	assumeRoleExpiryWindow = time.Minute
+
+	envContainerCredentialsRelativeURI = "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"
+	envContainerCredentialsFullURI     = "AWS_CONTAINER_CREDENTIALS_FULL_URI"
+)
+
+// newCredentials returns the credentials of the configured source. Without an explicit source
+// the chain static keys, environment, shared credentials file, web identity token file and
+// ECS/EC2 role is tried in order and the first source which provides credentials is used.
+// When a role to assume is configured these credentials are only used to call STS.
+func newCredentials(sess *session.Session, opts *Options) (*credentials.Credentials, error) {
+	var creds *credentials.Credentials
+
+	if opts.CredentialsSource == "" {
+		creds = credentials.NewCredentials(&credentials.ChainProvider{
+			Providers:     credentialsChain(sess, opts),
+			VerboseErrors: true,
+		})
+	} else {
+		provider, err := credentialsProvider(sess, opts, opts.CredentialsSource)
+
+		if err != nil {
+			return nil, err
+		}
+
+		creds = credentials.NewCredentials(provider)
+	}
+
+	if opts.AssumeRoleARN == "" {
+		return creds, nil
+	}
+
+	return credentials.NewCredentials(assumeRoleProvider(sess, creds, opts)), nil
+}
+
+func assumeRoleProvider(
+	sess *session.Session,
+	creds *credentials.Credentials,
+	opts *Options,
+) *stscreds.AssumeRoleProvider {
+	provider := &stscreds.AssumeRoleProvider{
+		Client:          newSTSClient(sess, creds, opts),
+		RoleARN:         opts.AssumeRoleARN,
+		RoleSessionName: opts.RoleSessionName,
+		Duration:        opts.AssumeRoleDuration,
+		TokenProvider:   opts.AssumeRoleTokenProvider,
+		ExpiryWindow:    assumeRoleExpiryWindow,
+	}
+
+	if provider.RoleSessionName == "" {
+		provider.RoleSessionName = defaultRoleSessionName()
+	}
+
+	if provider.Duration == 0 {
+		provider.Duration = stscreds.DefaultDuration
+	}
+
+	if opts.AssumeRoleExternalID != "" {
+		provider.ExternalID = aws.String(opts.AssumeRoleExternalID)
+	}
+
+	if opts.AssumeRoleMFASerial != "" {
+		provider.SerialNumber = aws.String(opts.AssumeRoleMFASerial)
+	}
+
+	return provider
+}
+
+func newSTSClient(sess *session.Session, creds *credentials.Credentials, opts *Options) *sts.STS {
+	cfg := &aws.Config{}
+
+	if creds != nil {
+		cfg.Credentials = creds
+	}
+
+	if opts.STSEndpoint != "" {
+		cfg.Endpoint = aws.String(opts.STSEndpoint)
+	}
+
+	return sts.New(sess, cfg)
+}
+
+func credentialsChain(sess *session.Session, opts *Options) []credentials.Provider {
+	sources := []string{CredentialsSourceEnv, CredentialsSourceShared}
+
+	if opts.AccessKeyId != "" && opts.SecretAccessKey != "" {
+		sources = append([]string{CredentialsSourceStatic}, sources...)
+	}
+
+	if opts.WebIdentityTokenFile != "" && opts.WebIdentityRoleARN != "" {
+		sources = append(sources, CredentialsSourceWebIdentity)
+	}
+
+	providers := make([]credentials.Provider, 0, len(sources)+1)
+
+	for _, source := range sources {
+		provider, err := credentialsProvider(sess, opts, source)
+
+		if err == nil {
+			providers = append(providers, provider)
+		}
+	}
+
+	return append(providers, defaults.RemoteCredProvider(*sess.Config, sess.Handlers))
+}
+
+func credentialsProvider(sess *session.Session, opts *Options, source string) (credentials.Provider, error) {
+	switch source {
+	case CredentialsSourceStatic:
+		if opts.AccessKeyId == "" || opts.SecretAccessKey == "" {
+			return nil, fmt.Errorf("%s credentials source requires access key id and secret access key", source)
+		}
+
+		return &credentials.StaticProvider{
+			Value: credentials.Value{
+				AccessKeyID:     opts.AccessKeyId,
+				SecretAccessKey: opts.SecretAccessKey,
+				SessionToken:    opts.Token,
+			},
+		}, nil
+	case CredentialsSourceEnv:
+		return &credentials.EnvProvider{}, nil
+	case CredentialsSourceShared:
+		return &credentials.SharedCredentialsProvider{
+			Filename: opts.SharedCredentialsFile,
+			Profile:  opts.Profile,
+		}, nil
+	case CredentialsSourceEC2:
+		return &ec2rolecreds.EC2RoleProvider{Client: ec2metadata.New(sess)}, nil
+	case CredentialsSourceECS:
+		if os.Getenv(envContainerCredentialsRelativeURI) == "" && os.Getenv(envContainerCredentialsFullURI) == "" {
+			return nil, fmt.Errorf(
+				"%s credentials source requires %s or %s",
+				source,
+				envContainerCredentialsRelativeURI,
+				envContainerCredentialsFullURI,
+			)
+		}
+
+		return defaults.RemoteCredProvider(*sess.Config, sess.Handlers), nil
+	case CredentialsSourceWebIdentity:
+		if opts.WebIdentityTokenFile == "" || opts.WebIdentityRoleARN == "" {
+			return nil, fmt.Errorf("%s credentials source requires token file and role arn", source)
+		}
+
+		sessionName := opts.RoleSessionName
+
+		if sessionName == "" {
+			sessionName = defaultRoleSessionName()
+		}
+
+		return stscreds.NewWebIdentityRoleProvider(
+			newSTSClient(sess, nil, opts),
+			opts.WebIdentityRoleARN,
+			sessionName,
+			opts.WebIdentityTokenFile,
+		), nil
+	}
+
+	return nil, fmt.Errorf("unknown credentials source %q", source)
+}
+
+func defaultRoleSessionName() string {
+	return "aws-manager-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+}
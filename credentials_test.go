@@ -0,0 +1,322 @@
+package aws_manager
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	assumeRoleARN = "arn:aws:iam::123456789012:role/storage"
+
+	sharedCredentialsContent = `[default]
+aws_access_key_id = default_key
+aws_secret_access_key = default_secret
+
+[merchant]
+aws_access_key_id = merchant_key
+aws_secret_access_key = merchant_secret
+`
+)
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_StaticCredentials_Ok() {
+	manager, err := New(
+		AccessKeyId("static_key"),
+		SecretAccessKey("static_secret"),
+		Region("eu-west-1"),
+		Bucket("bucket"),
+	)
+	assert.NoError(suite.T(), err)
+
+	creds, err := manager.(*AwsManager).credentials.Get()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), credentials.StaticProviderName, creds.ProviderName)
+	assert.Equal(suite.T(), "static_key", creds.AccessKeyID)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_WithoutStaticKeys_EnvCredentials_Ok() {
+	defer suite.unsetEnv("AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY")()
+	defer suite.setEnv("AWS_ACCESS_KEY", "env_key")()
+	defer suite.setEnv("AWS_SECRET_KEY", "env_secret")()
+
+	manager, err := New()
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), manager.(*AwsManager).cfg.AccessKeyId)
+
+	creds, err := manager.(*AwsManager).credentials.Get()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), credentials.EnvProviderName, creds.ProviderName)
+	assert.Equal(suite.T(), "env_key", creds.AccessKeyID)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_WithoutStaticKeys_SharedCredentials_Ok() {
+	defer suite.unsetEnv("AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY")()
+
+	manager, err := New(
+		Bucket("bucket"),
+		SharedCredentialsFile(suite.newSharedCredentialsFile()),
+		Profile("merchant"),
+	)
+	assert.NoError(suite.T(), err)
+
+	creds, err := manager.(*AwsManager).credentials.Get()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), credentials.SharedCredsProviderName, creds.ProviderName)
+	assert.Equal(suite.T(), "merchant_key", creds.AccessKeyID)
+	assert.Equal(suite.T(), "merchant_secret", creds.SecretAccessKey)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_SharedCredentialsSource_StaticKeys_Error() {
+	manager, err := New(
+		CredentialsSource(CredentialsSourceShared),
+		SharedCredentialsFile(suite.newSharedCredentialsFile()),
+	)
+	assert.IsType(suite.T(), ValidationErrors{}, err)
+	assert.Regexp(suite.T(), `"shared" conflicts with AccessKeyId`, err.(ValidationErrors).Field("CredentialsSource").Reason)
+	assert.Nil(suite.T(), manager)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_EnvCredentialsSource_Ok() {
+	defer suite.setEnv("AWS_CREDENTIALS_SOURCE", CredentialsSourceEnv)()
+
+	manager, err := New()
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), manager.(*AwsManager).cfg.AccessKeyId)
+
+	creds, err := manager.(*AwsManager).credentials.Get()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), credentials.EnvProviderName, creds.ProviderName)
+	assert.Equal(suite.T(), os.Getenv("AWS_ACCESS_KEY_ID"), creds.AccessKeyID)
+
+	_, err = New(AccessKeyId("static_key"), SecretAccessKey("static_secret"))
+	assert.Error(suite.T(), err)
+	assert.NotNil(suite.T(), err.(ValidationErrors).Field("CredentialsSource"))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_CredentialsSourceFromEnv_Ok() {
+	defer suite.unsetEnv("AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY")()
+	defer suite.setEnv("AWS_CREDENTIALS_SOURCE", CredentialsSourceShared)()
+	defer suite.setEnv("AWS_SHARED_CREDENTIALS_FILE", suite.newSharedCredentialsFile())()
+	defer suite.setEnv("AWS_PROFILE", "merchant")()
+
+	manager, err := New()
+	assert.NoError(suite.T(), err)
+
+	creds, err := manager.(*AwsManager).credentials.Get()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "merchant_key", creds.AccessKeyID)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_UnknownCredentialsSource_Error() {
+	manager, err := New(CredentialsSource("vault"))
+	assert.Error(suite.T(), err)
+	assert.Regexp(suite.T(), "vault", err.Error())
+	assert.Nil(suite.T(), manager)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_StaticCredentialsSourceWithoutKeys_Error() {
+	defer suite.unsetEnv("AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY")()
+
+	manager, err := New(CredentialsSource(CredentialsSourceStatic))
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), manager)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_CredentialsProvider_EC2_Ok() {
+	provider, err := credentialsProvider(suite.newSession(), &Options{}, CredentialsSourceEC2)
+	assert.NoError(suite.T(), err)
+	assert.IsType(suite.T(), &ec2rolecreds.EC2RoleProvider{}, provider)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_CredentialsProvider_ECSWithoutEndpoint_Error() {
+	defer suite.unsetEnv(envContainerCredentialsRelativeURI, envContainerCredentialsFullURI)()
+
+	_, err := credentialsProvider(suite.newSession(), &Options{}, CredentialsSourceECS)
+	assert.Error(suite.T(), err)
+	assert.Regexp(suite.T(), envContainerCredentialsRelativeURI, err.Error())
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_CredentialsProvider_ECS_Ok() {
+	defer suite.setEnv(envContainerCredentialsRelativeURI, "/v2/credentials/id")()
+
+	provider, err := credentialsProvider(suite.newSession(), &Options{}, CredentialsSourceECS)
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), provider)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_CredentialsProvider_WebIdentity_Ok() {
+	opts := &Options{
+		WebIdentityTokenFile: "/var/run/secrets/token",
+		WebIdentityRoleARN:   "arn:aws:iam::123456789012:role/s3",
+	}
+	provider, err := credentialsProvider(suite.newSession(), opts, CredentialsSourceWebIdentity)
+	assert.NoError(suite.T(), err)
+	assert.IsType(suite.T(), &stscreds.WebIdentityRoleProvider{}, provider)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_CredentialsProvider_WebIdentityWithoutRole_Error() {
+	opts := &Options{WebIdentityTokenFile: "/var/run/secrets/token"}
+	_, err := credentialsProvider(suite.newSession(), opts, CredentialsSourceWebIdentity)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_CredentialsChain_WebIdentityOnlyWhenConfigured() {
+	chain := credentialsChain(suite.newSession(), &Options{})
+	assert.Len(suite.T(), chain, 3)
+
+	opts := &Options{
+		AccessKeyId:          "key",
+		SecretAccessKey:      "secret",
+		WebIdentityTokenFile: "/var/run/secrets/token",
+		WebIdentityRoleARN:   "arn:aws:iam::123456789012:role/s3",
+	}
+	chain = credentialsChain(suite.newSession(), opts)
+	assert.Len(suite.T(), chain, 5)
+	assert.IsType(suite.T(), &credentials.StaticProvider{}, chain[0])
+	assert.IsType(suite.T(), &stscreds.WebIdentityRoleProvider{}, chain[3])
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_AssumeRole_Ok() {
+	server := test.NewSTSServer()
+	defer server.Close()
+
+	manager, err := New(
+		AccessKeyId("base_key"),
+		SecretAccessKey("base_secret"),
+		Bucket("bucket"),
+		AssumeRole(assumeRoleARN),
+		AssumeRoleExternalID("external_id"),
+		AssumeRoleDuration(30*time.Minute),
+		RoleSessionName("aws-manager-test"),
+		STSEndpoint(server.URL),
+	)
+	assert.NoError(suite.T(), err)
+
+	creds, err := manager.(*AwsManager).credentials.Get()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), stscreds.ProviderName, creds.ProviderName)
+	assert.Equal(suite.T(), "ASIA1", creds.AccessKeyID)
+	assert.Equal(suite.T(), "secret1", creds.SecretAccessKey)
+	assert.Equal(suite.T(), "token1", creds.SessionToken)
+
+	requests := server.Requests()
+	assert.Len(suite.T(), requests, 1)
+	assert.Equal(suite.T(), assumeRoleARN, requests[0].Get("RoleArn"))
+	assert.Equal(suite.T(), "aws-manager-test", requests[0].Get("RoleSessionName"))
+	assert.Equal(suite.T(), "external_id", requests[0].Get("ExternalId"))
+	assert.Equal(suite.T(), "1800", requests[0].Get("DurationSeconds"))
+	assert.Contains(suite.T(), server.Authorization()[0], "Credential=base_key/")
+
+	_, err = manager.(*AwsManager).credentials.Get()
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), server.Requests(), 1)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_AssumeRole_RefreshBeforeExpiry() {
+	server := test.NewSTSServer()
+	server.TTL = assumeRoleExpiryWindow / 2
+	defer server.Close()
+
+	manager, err := New(AssumeRole(assumeRoleARN), STSEndpoint(server.URL))
+	assert.NoError(suite.T(), err)
+
+	creds, err := manager.(*AwsManager).credentials.Get()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "ASIA1", creds.AccessKeyID)
+
+	creds, err = manager.(*AwsManager).credentials.Get()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "ASIA2", creds.AccessKeyID)
+	assert.Len(suite.T(), server.Requests(), 2)
+	assert.NotEmpty(suite.T(), server.Requests()[0].Get("RoleSessionName"))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_AssumeRoleMFA_Ok() {
+	server := test.NewSTSServer()
+	server.TTL = assumeRoleExpiryWindow / 2
+	defer server.Close()
+
+	calls := 0
+	tokenProvider := func() (string, error) {
+		calls++
+		return "123456", nil
+	}
+
+	manager, err := New(
+		AssumeRole(assumeRoleARN),
+		AssumeRoleMFA("arn:aws:iam::123456789012:mfa/user", tokenProvider),
+		STSEndpoint(server.URL),
+	)
+	assert.NoError(suite.T(), err)
+
+	for i := 0; i < 2; i++ {
+		_, err = manager.(*AwsManager).credentials.Get()
+		assert.NoError(suite.T(), err)
+	}
+
+	assert.Equal(suite.T(), 2, calls)
+
+	requests := server.Requests()
+	assert.Len(suite.T(), requests, 2)
+	assert.Equal(suite.T(), "arn:aws:iam::123456789012:mfa/user", requests[1].Get("SerialNumber"))
+	assert.Equal(suite.T(), "123456", requests[1].Get("TokenCode"))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_AssumeRoleMFAWithoutTokenProvider_Error() {
+	server := test.NewSTSServer()
+	defer server.Close()
+
+	manager, err := New(
+		AssumeRole(assumeRoleARN),
+		AssumeRoleMFA("arn:aws:iam::123456789012:mfa/user", nil),
+		STSEndpoint(server.URL),
+	)
+	assert.NoError(suite.T(), err)
+
+	_, err = manager.(*AwsManager).credentials.Get()
+	assert.Error(suite.T(), err)
+	assert.Empty(suite.T(), server.Requests())
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_AssumeRoleFromEnv_Ok() {
+	server := test.NewSTSServer()
+	defer server.Close()
+
+	defer suite.setEnv("AWS_ASSUME_ROLE_ARN", assumeRoleARN)()
+	defer suite.setEnv("AWS_ASSUME_ROLE_DURATION", "20m")()
+	defer suite.setEnv("AWS_STS_ENDPOINT", server.URL)()
+
+	manager, err := New()
+	assert.NoError(suite.T(), err)
+
+	creds, err := manager.(*AwsManager).credentials.Get()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "ASIA1", creds.AccessKeyID)
+	assert.Equal(suite.T(), "1200", server.Requests()[0].Get("DurationSeconds"))
+}
+
+func (suite *AwsManagerTestSuite) newSession() *session.Session {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String("eu-west-1")})
+	assert.NoError(suite.T(), err)
+
+	return sess
+}
+
+func (suite *AwsManagerTestSuite) newSharedCredentialsFile() string {
+	dir, err := ioutil.TempDir("", "aws_manager")
+	assert.NoError(suite.T(), err)
+
+	path := filepath.Join(dir, "credentials")
+	err = ioutil.WriteFile(path, []byte(sharedCredentialsContent), 0600)
+	assert.NoError(suite.T(), err)
+
+	return path
+}
@@ -0,0 +1,189 @@
+package aws_manager
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	deleteManyMaxObjects = 1000
+)
+
+type DeleteInput struct {
+	Bucket                    string
+	BypassGovernanceRetention bool
+	FileName                  string
+	MFA                       string
+	RequestPayer              string
+	VersionId                 string
+}
+
+type DeleteManyInput struct {
+	Bucket                    string
+	BypassGovernanceRetention bool
+	MFA                       string
+	Objects                   []*DeleteObject
+	RequestPayer              string
+}
+
+type DeleteObject struct {
+	FileName  string
+	VersionId string
+}
+
+type DeleteManyOutput struct {
+	Deleted []*DeletedObject
+	Errors  []*DeleteObjectError
+}
+
+type DeletedObject struct {
+	FileName              string
+	VersionId             string
+	DeleteMarker          bool
+	DeleteMarkerVersionId string
+}
+
+type DeleteObjectError struct {
+	FileName  string
+	VersionId string
+	Code      string
+	Message   string
+}
+
+func (m *AwsManager) Delete(ctx context.Context, in *DeleteInput) (*s3.DeleteObjectOutput, error) {
+	if in.Bucket == "" {
+		in.Bucket = m.cfg.Bucket
+	}
+
+	s3In := in.toAwsDeleteObjectInput()
+	out, err := m.awsS3.DeleteObjectWithContext(ctx, s3In)
+
+	if err != nil {
+		return nil, wrapError(err, "Delete", in.Bucket, in.FileName, false)
+	}
+
+	return out, nil
+}
+
+// DeleteMany removes the objects in batches of up to 1000 keys per DeleteObjects call.
+// When a batch request fails the results collected so far are returned together with the error.
+func (m *AwsManager) DeleteMany(ctx context.Context, in *DeleteManyInput) (*DeleteManyOutput, error) {
+	if in.Bucket == "" {
+		in.Bucket = m.cfg.Bucket
+	}
+
+	out := &DeleteManyOutput{}
+
+	for start := 0; start < len(in.Objects); start += deleteManyMaxObjects {
+		end := start + deleteManyMaxObjects
+
+		if end > len(in.Objects) {
+			end = len(in.Objects)
+		}
+
+		s3In := in.toAwsDeleteObjectsInput(in.Objects[start:end])
+		s3Out, err := m.awsS3.DeleteObjectsWithContext(ctx, s3In)
+
+		if err != nil {
+			return out, wrapError(err, "DeleteMany", in.Bucket, "", false)
+		}
+
+		out.append(s3Out)
+	}
+
+	return out, nil
+}
+
+func (m *DeleteObjectError) Error() string {
+	return fmt.Sprintf("delete %s failed: %s: %s", m.FileName, m.Code, m.Message)
+}
+
+func (m *DeleteManyOutput) append(in *s3.DeleteObjectsOutput) {
+	for _, v := range in.Deleted {
+		m.Deleted = append(m.Deleted, &DeletedObject{
+			FileName:              aws.StringValue(v.Key),
+			VersionId:             aws.StringValue(v.VersionId),
+			DeleteMarker:          aws.BoolValue(v.DeleteMarker),
+			DeleteMarkerVersionId: aws.StringValue(v.DeleteMarkerVersionId),
+		})
+	}
+
+	for _, v := range in.Errors {
+		m.Errors = append(m.Errors, &DeleteObjectError{
+			FileName:  aws.StringValue(v.Key),
+			VersionId: aws.StringValue(v.VersionId),
+			Code:      aws.StringValue(v.Code),
+			Message:   aws.StringValue(v.Message),
+		})
+	}
+}
+
+func (m *DeleteInput) toAwsDeleteObjectInput() *s3.DeleteObjectInput {
+	out := &s3.DeleteObjectInput{}
+
+	if m.Bucket != "" {
+		out.Bucket = aws.String(m.Bucket)
+	}
+
+	if m.BypassGovernanceRetention {
+		out.BypassGovernanceRetention = aws.Bool(m.BypassGovernanceRetention)
+	}
+
+	if m.FileName != "" {
+		out.Key = aws.String(m.FileName)
+	}
+
+	if m.MFA != "" {
+		out.MFA = aws.String(m.MFA)
+	}
+
+	if m.RequestPayer != "" {
+		out.RequestPayer = aws.String(m.RequestPayer)
+	}
+
+	if m.VersionId != "" {
+		out.VersionId = aws.String(m.VersionId)
+	}
+
+	return out
+}
+
+func (m *DeleteManyInput) toAwsDeleteObjectsInput(objects []*DeleteObject) *s3.DeleteObjectsInput {
+	out := &s3.DeleteObjectsInput{
+		Delete: &s3.Delete{
+			Objects: make([]*s3.ObjectIdentifier, 0, len(objects)),
+		},
+	}
+
+	if m.Bucket != "" {
+		out.Bucket = aws.String(m.Bucket)
+	}
+
+	if m.BypassGovernanceRetention {
+		out.BypassGovernanceRetention = aws.Bool(m.BypassGovernanceRetention)
+	}
+
+	if m.MFA != "" {
+		out.MFA = aws.String(m.MFA)
+	}
+
+	if m.RequestPayer != "" {
+		out.RequestPayer = aws.String(m.RequestPayer)
+	}
+
+	for _, v := range objects {
+		obj := &s3.ObjectIdentifier{
+			Key: aws.String(v.FileName),
+		}
+
+		if v.VersionId != "" {
+			obj.VersionId = aws.String(v.VersionId)
+		}
+
+		out.Delete.Objects = append(out.Delete.Objects, obj)
+	}
+
+	return out
+}
@@ -0,0 +1,148 @@
+package aws_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Delete_WithoutBucket_Ok() {
+	in := &DeleteInput{
+		FileName:                  fileName,
+		VersionId:                 "VersionId",
+		MFA:                       "MFA",
+		RequestPayer:              "RequestPayer",
+		BypassGovernanceRetention: true,
+	}
+	_, err := suite.awsManager.Delete(context.TODO(), in)
+	assert.NoError(suite.T(), err)
+
+	mockS3 := suite.awsManager.(*AwsManager).awsS3.(*test.S3API)
+	s3In := mockS3.Calls[0].Arguments.Get(1).(*s3.DeleteObjectInput)
+	assert.Equal(suite.T(), suite.awsManager.(*AwsManager).cfg.Bucket, aws.StringValue(s3In.Bucket))
+	assert.Equal(suite.T(), fileName, aws.StringValue(s3In.Key))
+	assert.Equal(suite.T(), "VersionId", aws.StringValue(s3In.VersionId))
+	assert.Equal(suite.T(), "MFA", aws.StringValue(s3In.MFA))
+	assert.Equal(suite.T(), "RequestPayer", aws.StringValue(s3In.RequestPayer))
+	assert.True(suite.T(), aws.BoolValue(s3In.BypassGovernanceRetention))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Delete_WithBucket_Ok() {
+	in := &DeleteInput{
+		Bucket:   "bucket-name",
+		FileName: fileName,
+	}
+	_, err := suite.awsManager.Delete(context.TODO(), in)
+	assert.NoError(suite.T(), err)
+
+	mockS3 := suite.awsManager.(*AwsManager).awsS3.(*test.S3API)
+	s3In := mockS3.Calls[0].Arguments.Get(1).(*s3.DeleteObjectInput)
+	assert.Equal(suite.T(), "bucket-name", aws.StringValue(s3In.Bucket))
+	assert.Nil(suite.T(), s3In.VersionId)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_DeleteMany_SplitsIntoBatches_Ok() {
+	mockS3 := &test.S3API{}
+	mockS3.On("DeleteObjectsWithContext", mock.Anything, mock.Anything).
+		Return(
+			func(_ context.Context, in *s3.DeleteObjectsInput, _ ...request.Option) *s3.DeleteObjectsOutput {
+				out := &s3.DeleteObjectsOutput{}
+
+				for _, v := range in.Delete.Objects {
+					out.Deleted = append(out.Deleted, &s3.DeletedObject{Key: v.Key, VersionId: v.VersionId})
+				}
+
+				return out
+			},
+			nil,
+		)
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	in := &DeleteManyInput{MFA: "MFA", RequestPayer: "RequestPayer"}
+
+	for i := 0; i < 2500; i++ {
+		in.Objects = append(in.Objects, &DeleteObject{FileName: fmt.Sprintf("file_%d.pdf", i)})
+	}
+
+	in.Objects[2499].VersionId = "VersionId"
+
+	out, err := suite.awsManager.DeleteMany(context.TODO(), in)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), out.Deleted, 2500)
+	assert.Empty(suite.T(), out.Errors)
+	assert.Equal(suite.T(), "file_2499.pdf", out.Deleted[2499].FileName)
+	assert.Equal(suite.T(), "VersionId", out.Deleted[2499].VersionId)
+
+	mockS3.AssertNumberOfCalls(suite.T(), "DeleteObjectsWithContext", 3)
+
+	for i, size := range []int{1000, 1000, 500} {
+		s3In := mockS3.Calls[i].Arguments.Get(1).(*s3.DeleteObjectsInput)
+		assert.Len(suite.T(), s3In.Delete.Objects, size)
+		assert.Equal(suite.T(), suite.awsManager.(*AwsManager).cfg.Bucket, aws.StringValue(s3In.Bucket))
+		assert.Equal(suite.T(), "MFA", aws.StringValue(s3In.MFA))
+		assert.Equal(suite.T(), "RequestPayer", aws.StringValue(s3In.RequestPayer))
+	}
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_DeleteMany_PerKeyErrors_Ok() {
+	mockS3 := &test.S3API{}
+	mockS3.On("DeleteObjectsWithContext", mock.Anything, mock.Anything).
+		Return(
+			&s3.DeleteObjectsOutput{
+				Deleted: []*s3.DeletedObject{{Key: aws.String("file_1.pdf"), DeleteMarker: aws.Bool(true)}},
+				Errors: []*s3.Error{
+					{Key: aws.String("file_2.pdf"), Code: aws.String("AccessDenied"), Message: aws.String("Access Denied")},
+				},
+			},
+			nil,
+		)
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	in := &DeleteManyInput{
+		Bucket:  "bucket-name",
+		Objects: []*DeleteObject{{FileName: "file_1.pdf"}, {FileName: "file_2.pdf"}},
+	}
+	out, err := suite.awsManager.DeleteMany(context.TODO(), in)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), out.Deleted, 1)
+	assert.True(suite.T(), out.Deleted[0].DeleteMarker)
+	assert.Len(suite.T(), out.Errors, 1)
+	assert.Equal(suite.T(), "file_2.pdf", out.Errors[0].FileName)
+	assert.Equal(suite.T(), "AccessDenied", out.Errors[0].Code)
+	assert.Regexp(suite.T(), "file_2.pdf", out.Errors[0].Error())
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_DeleteMany_RequestError() {
+	mockS3 := &test.S3API{}
+	mockS3.On("DeleteObjectsWithContext", mock.Anything, mock.Anything).
+		Return(&s3.DeleteObjectsOutput{Deleted: []*s3.DeletedObject{{Key: aws.String("file_0.pdf")}}}, nil).Once()
+	mockS3.On("DeleteObjectsWithContext", mock.Anything, mock.Anything).
+		Return(nil, errors.New("request failed")).Once()
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	in := &DeleteManyInput{}
+
+	for i := 0; i < 1001; i++ {
+		in.Objects = append(in.Objects, &DeleteObject{FileName: fmt.Sprintf("file_%d.pdf", i)})
+	}
+
+	out, err := suite.awsManager.DeleteMany(context.TODO(), in)
+	assert.Error(suite.T(), err)
+	assert.Regexp(suite.T(), "request failed", err.Error())
+	assert.Len(suite.T(), out.Deleted, 1)
+	mockS3.AssertNumberOfCalls(suite.T(), "DeleteObjectsWithContext", 2)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_DeleteMany_EmptyObjects_Ok() {
+	out, err := suite.awsManager.DeleteMany(context.TODO(), &DeleteManyInput{})
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), out.Deleted)
+	assert.Empty(suite.T(), out.Errors)
+	suite.awsManager.(*AwsManager).awsS3.(*test.S3API).AssertNotCalled(suite.T(), "DeleteObjectsWithContext")
+}
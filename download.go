@@ -0,0 +1,223 @@
+package aws_manager
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// downloadFileMode is the mode of new files before the umask, the same as os.Create uses.
+	downloadFileMode      = os.FileMode(0666)
+	downloadTempFileTries = 10000
+)
+
+type DownloadOutput struct {
+	Size                 int64
+	CacheControl         string
+	ContentDisposition   string
+	ContentEncoding      string
+	ContentLanguage      string
+	ContentType          string
+	ETag                 string
+	LastModified         time.Time
+	Metadata             map[string]string
+	RequestId            string
+	SSECustomerAlgorithm string
+	SSECustomerKeyMD5    string
+	SSEKMSKeyId          string
+	ServerSideEncryption string
+	StorageClass         string
+	VerifiedWith         string // IntegritySHA256 or IntegrityMD5 when the download was verified, empty otherwise
+	VersionId            string
+}
+
+// getObjectCapture collects GetObject responses received by the downloader,
+// which does not expose them otherwise.
+type getObjectCapture struct {
+	mu        sync.Mutex
+	ranged    bool
+	output    *s3.GetObjectOutput
+	requestId string
+	totalSize int64
+}
+
+// downloadFile writes the object into a temporary file next to path and renames it into place
+// only when the whole object has been received, so a failed download never leaves a partial file.
+func (m *AwsManager) downloadFile(
+	ctx context.Context,
+	path string,
+	in *DownloadInput,
+	opts ...func(*s3manager.Downloader),
+) (*DownloadOutput, error) {
+	var mode os.FileMode
+
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	file, err := createDownloadTempFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tmpPath := file.Name()
+	renamed := false
+
+	defer func() {
+		if !renamed {
+			_ = file.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	out, err := m.DownloadTo(ctx, file, in, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = file.Sync(); err != nil {
+		return nil, err
+	}
+
+	if (in.VerifyIntegrity || aws.BoolValue(m.cfg.VerifyIntegrity)) && in.Range == "" && in.PartNumber == nil {
+		if err = verifyIntegrity(file, out, in.Bucket, in.FileName); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = file.Close(); err != nil {
+		return nil, err
+	}
+
+	if mode != 0 {
+		if err = os.Chmod(tmpPath, mode); err != nil {
+			return nil, err
+		}
+	}
+
+	if in.PreserveLastModified && !out.LastModified.IsZero() {
+		if err = os.Chtimes(tmpPath, out.LastModified, out.LastModified); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = os.Rename(tmpPath, path); err != nil {
+		return nil, err
+	}
+
+	renamed = true
+	return out, nil
+}
+
+// createDownloadTempFile creates the temporary file of path. Unlike ioutil.TempFile, which always uses 0600,
+// the file is created with downloadFileMode so the umask applies to new downloads as it does for os.Create.
+func createDownloadTempFile(path string) (*os.File, error) {
+	prefix := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".")
+
+	for i := 0; i < downloadTempFileTries; i++ {
+		name := prefix + strconv.FormatUint(uint64(rand.Uint32()), 10) + ".tmp"
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, downloadFileMode)
+
+		if os.IsExist(err) {
+			continue
+		}
+
+		return file, err
+	}
+
+	return nil, &os.PathError{Op: "create", Path: prefix + "*.tmp", Err: os.ErrExist}
+}
+
+func newGetObjectCapture(in *DownloadInput) *getObjectCapture {
+	return &getObjectCapture{
+		ranged:    in.Range != "" || in.PartNumber != nil,
+		totalSize: -1,
+	}
+}
+
+func (c *getObjectCapture) option(d *s3manager.Downloader) {
+	opts := make([]request.Option, 0, len(d.RequestOptions)+1)
+	opts = append(opts, d.RequestOptions...)
+	d.RequestOptions = append(opts, func(r *request.Request) {
+		r.Handlers.Complete.PushBack(c.handle)
+	})
+}
+
+func (c *getObjectCapture) handle(r *request.Request) {
+	out, ok := r.Data.(*s3.GetObjectOutput)
+
+	if r.Error != nil || !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.output != nil {
+		return
+	}
+
+	c.output = out
+	c.requestId = r.RequestID
+
+	if c.ranged || out.ContentRange == nil {
+		c.totalSize = aws.Int64Value(out.ContentLength)
+		return
+	}
+
+	parts := strings.Split(aws.StringValue(out.ContentRange), "/")
+
+	if total, err := strconv.ParseInt(parts[len(parts)-1], 10, 64); err == nil {
+		c.totalSize = total
+	}
+}
+
+func (c *getObjectCapture) size() (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.totalSize, c.totalSize >= 0
+}
+
+func (c *getObjectCapture) downloadOutput(size int64) *DownloadOutput {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := &DownloadOutput{
+		Size:      size,
+		RequestId: c.requestId,
+	}
+
+	if c.output == nil {
+		return out
+	}
+
+	out.CacheControl = aws.StringValue(c.output.CacheControl)
+	out.ContentDisposition = aws.StringValue(c.output.ContentDisposition)
+	out.ContentEncoding = aws.StringValue(c.output.ContentEncoding)
+	out.ContentLanguage = aws.StringValue(c.output.ContentLanguage)
+	out.ContentType = aws.StringValue(c.output.ContentType)
+	out.ETag = aws.StringValue(c.output.ETag)
+	out.LastModified = aws.TimeValue(c.output.LastModified)
+	out.Metadata = aws.StringValueMap(c.output.Metadata)
+	out.SSECustomerAlgorithm = aws.StringValue(c.output.SSECustomerAlgorithm)
+	out.SSECustomerKeyMD5 = aws.StringValue(c.output.SSECustomerKeyMD5)
+	out.SSEKMSKeyId = aws.StringValue(c.output.SSEKMSKeyId)
+	out.ServerSideEncryption = aws.StringValue(c.output.ServerSideEncryption)
+	out.StorageClass = aws.StringValue(c.output.StorageClass)
+	out.VersionId = aws.StringValue(c.output.VersionId)
+
+	return out
+}
@@ -0,0 +1,199 @@
+package aws_manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultDownloadPrefixConcurrency = 5
+	defaultDownloadDirMode           = os.FileMode(0755)
+)
+
+// DownloadPrefixInput describes how the objects under a prefix are downloaded.
+// Defaults is copied for every object, its FileName is ignored and its Bucket selects the bucket.
+type DownloadPrefixInput struct {
+	Concurrency int
+	Defaults    DownloadInput
+	FailFast    bool
+}
+
+// DownloadPrefixOutput reports every object under the prefix together with the totals of the call.
+type DownloadPrefixOutput struct {
+	Files      []*DownloadPrefixResult
+	Downloaded int
+	Skipped    int
+	Bytes      int64
+}
+
+// DownloadPrefixResult is the outcome of one object, Path is relative to the local directory.
+// Skipped is set when the local file already had the size and ETag of the object.
+type DownloadPrefixResult struct {
+	FileName string
+	Path     string
+	Size     int64
+	ETag     string
+	Skipped  bool
+	Output   *DownloadOutput
+	Err      error
+}
+
+// DownloadPrefix downloads the objects under keyPrefix into localDir, recreating the hierarchy of the keys
+// relative to the prefix. Keys which would leave localDir fail with ErrUnsafeKey, keys ending with
+// a slash are taken for folder markers and ignored. Files with the size and ETag of their object are kept.
+// A failed object does not stop the others unless FailFast is set, then the remaining downloads
+// are canceled and the first error is returned together with the results collected so far.
+func (m *AwsManager) DownloadPrefix(
+	ctx context.Context,
+	keyPrefix string,
+	localDir string,
+	in *DownloadPrefixInput,
+) (*DownloadPrefixOutput, error) {
+	if in == nil {
+		in = &DownloadPrefixInput{}
+	}
+
+	if in.Defaults.Bucket == "" {
+		in.Defaults.Bucket = m.cfg.Bucket
+	}
+
+	if keyPrefix != "" && !strings.HasSuffix(keyPrefix, "/") {
+		keyPrefix += "/"
+	}
+
+	var files []*DownloadPrefixResult
+	it := m.List(ctx, &ListInput{Bucket: in.Defaults.Bucket, Prefix: keyPrefix, RequestPayer: in.Defaults.RequestPayer})
+
+	for it.Next() {
+		object := it.Object()
+
+		if strings.HasSuffix(object.FileName, "/") {
+			continue
+		}
+
+		files = append(files, &DownloadPrefixResult{
+			FileName: object.FileName,
+			Path:     strings.TrimPrefix(object.FileName, keyPrefix),
+			Size:     object.Size,
+			ETag:     object.ETag,
+		})
+	}
+
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	concurrency := in.Concurrency
+
+	if concurrency <= 0 {
+		concurrency = defaultDownloadPrefixConcurrency
+	}
+
+	err := runPool(ctx, concurrency, len(files), in.FailFast, func(ctx context.Context, i int) error {
+		return m.downloadPrefixFile(ctx, localDir, in, files[i])
+	})
+
+	out := &DownloadPrefixOutput{Files: files}
+
+	for _, v := range files {
+		switch {
+		case v.Err != nil:
+		case v.Skipped:
+			out.Skipped++
+		default:
+			out.Downloaded++
+			out.Bytes += v.Output.Size
+		}
+	}
+
+	return out, err
+}
+
+// Failed returns the results of the objects which were not downloaded.
+func (m *DownloadPrefixOutput) Failed() []*DownloadPrefixResult {
+	var failed []*DownloadPrefixResult
+
+	for _, v := range m.Files {
+		if v.Err != nil {
+			failed = append(failed, v)
+		}
+	}
+
+	return failed
+}
+
+func (m *AwsManager) downloadPrefixFile(
+	ctx context.Context,
+	localDir string,
+	in *DownloadPrefixInput,
+	file *DownloadPrefixResult,
+) error {
+	if file.Err = ctx.Err(); file.Err != nil {
+		return file.Err
+	}
+
+	path, err := localPath(localDir, file.Path)
+
+	if err != nil {
+		file.Err = unsafeKeyError("DownloadPrefix", in.Defaults.Bucket, file.FileName, err)
+		return file.Err
+	}
+
+	if file.Skipped, err = m.localFileMatches(path, file.Size, file.ETag); err != nil || file.Skipped {
+		file.Err = err
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), defaultDownloadDirMode); err != nil {
+		file.Err = err
+		return err
+	}
+
+	fileIn := in.Defaults
+	fileIn.FileName = file.FileName
+	file.Output, file.Err = m.Download(ctx, path, &fileIn)
+
+	return file.Err
+}
+
+// localFileMatches tells whether path is a regular file with the size and ETag of the object.
+func (m *AwsManager) localFileMatches(path string, size int64, etag string) (bool, error) {
+	fi, err := os.Stat(path)
+
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if !fi.Mode().IsRegular() || fi.Size() != size {
+		return false, nil
+	}
+
+	return fileMatchesETag(path, size, etag, m.cfg.UploadPartSize)
+}
+
+// localPath maps the slash separated path relative to the prefix onto localDir,
+// rejecting absolute paths and ".." segments.
+func localPath(localDir, rel string) (string, error) {
+	if rel == "" || strings.HasPrefix(rel, "/") || strings.Contains(rel, "\\") {
+		return "", fmt.Errorf("path %q is not relative to %s", rel, localDir)
+	}
+
+	for _, segment := range strings.Split(rel, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("path %q leaves %s", rel, localDir)
+		}
+	}
+
+	return filepath.Join(localDir, filepath.FromSlash(rel)), nil
+}
+
+func unsafeKeyError(op, bucket, key string, err error) error {
+	return &Error{Op: op, Bucket: bucket, Key: key, Kind: ErrUnsafeKey, Err: err}
+}
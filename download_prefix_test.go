@@ -0,0 +1,147 @@
+package aws_manager
+
+import (
+	"bytes"
+	"context"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func (suite *AwsManagerTestSuite) TestAwsManager_DownloadPrefix_Ok() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	suite.s3Server.PutObject(standInBucket, "reports/2019-08/merchant-1/report.csv", &test.S3Object{Body: []byte("merchant-1")})
+	suite.s3Server.PutObject(standInBucket, "reports/2019-08/merchant-2/report.csv", &test.S3Object{Body: []byte("merchant-2")})
+	suite.s3Server.PutObject(standInBucket, "reports/summary.csv", &test.S3Object{Body: []byte("summary")})
+	suite.s3Server.PutObject(standInBucket, "reports/2019-09/", &test.S3Object{})
+	suite.s3Server.PutObject(standInBucket, "reports-old/summary.csv", &test.S3Object{Body: []byte("old")})
+
+	manager := suite.newStandInManager()
+	out, err := manager.DownloadPrefix(context.TODO(), "reports", dir, &DownloadPrefixInput{Concurrency: 2})
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), out.Failed())
+	assert.Len(suite.T(), out.Files, 3)
+	assert.Equal(suite.T(), 3, out.Downloaded)
+	assert.Equal(suite.T(), 0, out.Skipped)
+	assert.Equal(suite.T(), int64(len("merchant-1merchant-2summary")), out.Bytes)
+	assert.Equal(suite.T(), "2019-08/merchant-1/report.csv", out.Files[0].Path)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "2019-08", "merchant-2", "report.csv"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []byte("merchant-2"), content)
+
+	_, err = os.Stat(filepath.Join(dir, "2019-09"))
+	assert.True(suite.T(), os.IsNotExist(err))
+
+	assert.NoError(suite.T(), ioutil.WriteFile(filepath.Join(dir, "summary.csv"), []byte("SUMMARY"), 0644))
+
+	out, err = manager.DownloadPrefix(context.TODO(), "reports/", dir, nil)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, out.Downloaded)
+	assert.Equal(suite.T(), 2, out.Skipped)
+	assert.True(suite.T(), out.Files[0].Skipped)
+	assert.Nil(suite.T(), out.Files[0].Output)
+	assert.False(suite.T(), out.Files[2].Skipped)
+
+	content, err = ioutil.ReadFile(filepath.Join(dir, "summary.csv"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []byte("summary"), content)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_DownloadPrefix_SkipsMultipartUpload() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	manager := suite.newStandInManager(UploadPartSize(progressTestPartSize))
+	body := bytes.Repeat([]byte("a"), progressTestSize)
+
+	_, err := manager.Upload(context.TODO(), &UploadInput{Body: bytes.NewReader(body), FileName: "archive/data.bin"})
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), ioutil.WriteFile(filepath.Join(dir, "data.bin"), body, 0644))
+
+	out, err := manager.DownloadPrefix(context.TODO(), "archive", dir, nil)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, etagParts(out.Files[0].ETag))
+	assert.Equal(suite.T(), 1, out.Skipped)
+	assert.Equal(suite.T(), 0, out.Downloaded)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_DownloadPrefix_UnsafeKey() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	suite.s3Server.PutObject(standInBucket, "reports/../../escape.csv", &test.S3Object{Body: []byte("escape")})
+	suite.s3Server.PutObject(standInBucket, "reports/summary.csv", &test.S3Object{Body: []byte("summary")})
+
+	out, err := suite.newStandInManager().DownloadPrefix(context.TODO(), "reports", dir, nil)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, out.Downloaded)
+
+	failed := out.Failed()
+	assert.Len(suite.T(), failed, 1)
+	assert.Equal(suite.T(), "reports/../../escape.csv", failed[0].FileName)
+	assert.True(suite.T(), IsUnsafeKey(failed[0].Err))
+
+	e := failed[0].Err.(*Error)
+	assert.Equal(suite.T(), "DownloadPrefix", e.Op)
+	assert.Equal(suite.T(), standInBucket, e.Bucket)
+	assert.Equal(suite.T(), "reports/../../escape.csv", e.Key)
+	assert.Regexp(suite.T(), `^DownloadPrefix s3://stand-in-bucket/reports/\.\./\.\./escape\.csv: object key escapes the local directory: `, e.Error())
+
+	_, err = os.Stat(filepath.Join(dir, "..", "escape.csv"))
+	assert.True(suite.T(), os.IsNotExist(err))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_DownloadPrefix_Errors() {
+	_, err := suite.newStandInManager(MaxRetries(0)).DownloadPrefix(context.TODO(), "reports", os.TempDir(), &DownloadPrefixInput{
+		Defaults: DownloadInput{Bucket: "missing-bucket"},
+	})
+	assert.True(suite.T(), IsBucketNotFound(err))
+
+	suite.s3Server.PutObject(standInBucket, "reports/a.csv", &test.S3Object{Body: []byte("a")})
+	suite.s3Server.PutObject(standInBucket, "reports/b.csv", &test.S3Object{Body: []byte("b")})
+
+	out, err := suite.newStandInManager().DownloadPrefix(context.TODO(), "reports", "./not_exist/\x00", &DownloadPrefixInput{
+		Concurrency: 1,
+		FailFast:    true,
+	})
+	assert.Error(suite.T(), err)
+	assert.Error(suite.T(), out.Files[0].Err)
+	assert.Equal(suite.T(), context.Canceled, out.Files[1].Err)
+	assert.Equal(suite.T(), 0, out.Downloaded)
+}
+
+func (suite *AwsManagerTestSuite) TestFileMatchesETag() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "data.bin")
+	body := bytes.Repeat([]byte("a"), 2*awsCliPartSize+1)
+	assert.NoError(suite.T(), ioutil.WriteFile(path, body, 0644))
+
+	single, err := fileETag(path, 0)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, etagParts(single))
+
+	multipart, err := fileETag(path, awsCliPartSize)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, etagParts(multipart))
+
+	for _, etag := range []string{single, `"` + single + `"`, multipart} {
+		ok, err := fileMatchesETag(path, int64(len(body)), etag, 0)
+		assert.NoError(suite.T(), err)
+		assert.True(suite.T(), ok, etag)
+	}
+
+	ok, err := fileMatchesETag(path, int64(len(body)), "d41d8cd98f00b204e9800998ecf8427e-3", 0)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), ok)
+
+	exact, err := readerETag(bytes.NewReader(make([]byte, 2*etagPartSizeAlignment)), etagPartSizeAlignment)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, etagParts(exact))
+}
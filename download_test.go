@@ -0,0 +1,242 @@
+package aws_manager
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// newResponseDownloader returns a downloader mock which writes content and passes
+// the response through the request options the same way s3manager.Downloader does.
+func newResponseDownloader(content string, out *s3.GetObjectOutput, err error) *test.DownloaderAPI {
+	mockDownloader := &test.DownloaderAPI{}
+	mockDownloader.On("DownloadWithContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(
+			func(_ context.Context, w io.WriterAt, _ *s3.GetObjectInput, opts ...func(*s3manager.Downloader)) int64 {
+				d := &s3manager.Downloader{}
+
+				for _, opt := range opts {
+					opt(d)
+				}
+
+				r := &request.Request{Data: out, RequestID: "RequestId"}
+
+				for _, opt := range d.RequestOptions {
+					opt(r)
+				}
+
+				r.Handlers.Complete.Run(r)
+				n, _ := w.WriteAt([]byte(content), 0)
+
+				return int64(n)
+			},
+			err,
+		)
+
+	return mockDownloader
+}
+
+func (suite *AwsManagerTestSuite) newDownloadDir() string {
+	dir, err := ioutil.TempDir("", "aws_manager")
+	assert.NoError(suite.T(), err)
+
+	return dir
+}
+
+func (suite *AwsManagerTestSuite) assertNoTempFiles(dir string) {
+	matches, err := filepath.Glob(filepath.Join(dir, ".*.tmp"))
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), matches)
+}
+
+// createdFileMode returns the mode os.Create gives to a new file in dir under the current umask.
+func (suite *AwsManagerTestSuite) createdFileMode(dir string) os.FileMode {
+	file, err := os.Create(filepath.Join(dir, "created"))
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), file.Close())
+
+	fi, err := os.Stat(file.Name())
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), os.Remove(file.Name()))
+
+	return fi.Mode().Perm()
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Download_Atomic_Ok() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	lastModified := time.Date(2019, 9, 1, 10, 0, 0, 0, time.UTC)
+	s3Out := &s3.GetObjectOutput{
+		ContentLength: aws.Int64(7),
+		ContentRange:  aws.String("bytes 0-6/7"),
+		LastModified:  aws.Time(lastModified),
+	}
+	suite.awsManager.(*AwsManager).awsDownloader = newResponseDownloader("content", s3Out, nil)
+
+	path := filepath.Join(dir, fileName)
+	in := &DownloadInput{
+		FileName:             fileName,
+		PreserveLastModified: true,
+	}
+	out, err := suite.awsManager.Download(context.TODO(), path, in)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(7), out.Size)
+	assert.Equal(suite.T(), lastModified, out.LastModified)
+
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "content", string(b))
+
+	fi, err := os.Stat(path)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), lastModified.Equal(fi.ModTime()))
+	assert.Equal(suite.T(), suite.createdFileMode(dir), fi.Mode().Perm())
+	suite.assertNoTempFiles(dir)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Download_RangedContentLength_Ok() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	out := &s3.GetObjectOutput{
+		ContentLength: aws.Int64(7),
+		ContentRange:  aws.String("bytes 0-6/100"),
+	}
+	suite.awsManager.(*AwsManager).awsDownloader = newResponseDownloader("content", out, nil)
+
+	path := filepath.Join(dir, fileName)
+	_, err := suite.awsManager.Download(context.TODO(), path, &DownloadInput{FileName: fileName, Range: "bytes=0-6"})
+	assert.NoError(suite.T(), err)
+
+	fi, err := os.Stat(path)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), fi.ModTime().Before(time.Now().Add(-time.Minute)))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Download_ContentLengthMismatch_Error() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	out := &s3.GetObjectOutput{
+		ContentLength: aws.Int64(5242880),
+		ContentRange:  aws.String("bytes 0-5242879/10485760"),
+	}
+	suite.awsManager.(*AwsManager).awsDownloader = newResponseDownloader("content", out, nil)
+
+	path := filepath.Join(dir, fileName)
+	_, err := suite.awsManager.Download(context.TODO(), path, &DownloadInput{FileName: fileName})
+	assert.True(suite.T(), IsIncompleteDownload(err))
+	assert.True(suite.T(), IsRetryable(err))
+	assert.Equal(suite.T(), "Download s3://"+standInBucket+"/"+fileName+": downloaded size does not match object content length: received 7 of 10485760 bytes", err.Error())
+
+	_, err = os.Stat(path)
+	assert.True(suite.T(), os.IsNotExist(err))
+	suite.assertNoTempFiles(dir)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Download_RequestError_ExistingFileKept() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, fileName)
+	err := ioutil.WriteFile(path, []byte("previous"), 0600)
+	assert.NoError(suite.T(), err)
+
+	out := &s3.GetObjectOutput{ContentLength: aws.Int64(7)}
+	suite.awsManager.(*AwsManager).awsDownloader = newResponseDownloader("partial", out, errors.New("connection reset"))
+
+	_, err = suite.awsManager.Download(context.TODO(), path, &DownloadInput{FileName: fileName})
+	assert.Error(suite.T(), err)
+	assert.Regexp(suite.T(), "connection reset", err.Error())
+
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "previous", string(b))
+	suite.assertNoTempFiles(dir)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Download_ExistingFileModeKept_Ok() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, fileName)
+	err := ioutil.WriteFile(path, []byte("previous"), 0600)
+	assert.NoError(suite.T(), err)
+
+	out := &s3.GetObjectOutput{ContentLength: aws.Int64(7)}
+	suite.awsManager.(*AwsManager).awsDownloader = newResponseDownloader("content", out, nil)
+
+	_, err = suite.awsManager.Download(context.TODO(), path, &DownloadInput{FileName: fileName})
+	assert.NoError(suite.T(), err)
+
+	fi, err := os.Stat(path)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), os.FileMode(0600), fi.Mode().Perm())
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Download_Output_Ok() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	s3Out := &s3.GetObjectOutput{
+		CacheControl:         aws.String("no-cache"),
+		ContentDisposition:   aws.String("attachment"),
+		ContentEncoding:      aws.String("identity"),
+		ContentLanguage:      aws.String("en"),
+		ContentLength:        aws.Int64(7),
+		ContentType:          aws.String("application/pdf"),
+		ETag:                 aws.String(`"etag"`),
+		Metadata:             map[string]*string{"Merchant": aws.String("1")},
+		SSECustomerAlgorithm: aws.String("AES256"),
+		SSECustomerKeyMD5:    aws.String("SSECustomerKeyMD5"),
+		SSEKMSKeyId:          aws.String("SSEKMSKeyId"),
+		ServerSideEncryption: aws.String("aws:kms"),
+		StorageClass:         aws.String("STANDARD"),
+		VersionId:            aws.String("VersionId"),
+	}
+	suite.awsManager.(*AwsManager).awsDownloader = newResponseDownloader("content", s3Out, nil)
+
+	out, err := suite.awsManager.Download(context.TODO(), filepath.Join(dir, fileName), &DownloadInput{FileName: fileName})
+	assert.NoError(suite.T(), err)
+
+	expected := &DownloadOutput{
+		Size:                 7,
+		CacheControl:         "no-cache",
+		ContentDisposition:   "attachment",
+		ContentEncoding:      "identity",
+		ContentLanguage:      "en",
+		ContentType:          "application/pdf",
+		ETag:                 `"etag"`,
+		Metadata:             map[string]string{"Merchant": "1"},
+		RequestId:            "RequestId",
+		SSECustomerAlgorithm: "AES256",
+		SSECustomerKeyMD5:    "SSECustomerKeyMD5",
+		SSEKMSKeyId:          "SSEKMSKeyId",
+		ServerSideEncryption: "aws:kms",
+		StorageClass:         "STANDARD",
+		VersionId:            "VersionId",
+	}
+	assert.Equal(suite.T(), expected, out)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_DownloadTo_ContentLengthMismatch_Error() {
+	s3Out := &s3.GetObjectOutput{ContentLength: aws.Int64(10)}
+	suite.awsManager.(*AwsManager).awsDownloader = newResponseDownloader("content", s3Out, nil)
+
+	out, err := suite.awsManager.DownloadTo(context.TODO(), aws.NewWriteAtBuffer(nil), &DownloadInput{FileName: fileName})
+	assert.True(suite.T(), IsIncompleteDownload(err))
+	assert.Equal(suite.T(), fileName, err.(*Error).Key)
+	assert.Nil(suite.T(), out)
+}
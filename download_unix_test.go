@@ -0,0 +1,32 @@
+//go:build !windows
+// +build !windows
+
+package aws_manager
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Download_NewFileUmask_Ok() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+	defer syscall.Umask(syscall.Umask(077))
+
+	out := &s3.GetObjectOutput{ContentLength: aws.Int64(7)}
+	suite.awsManager.(*AwsManager).awsDownloader = newResponseDownloader("content", out, nil)
+
+	path := filepath.Join(dir, fileName)
+	_, err := suite.awsManager.Download(context.TODO(), path, &DownloadInput{FileName: fileName})
+	assert.NoError(suite.T(), err)
+
+	fi, err := os.Stat(path)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), os.FileMode(0600), fi.Mode().Perm())
+	assert.Equal(suite.T(), suite.createdFileMode(dir), fi.Mode().Perm())
+}
@@ -0,0 +1,146 @@
+package aws_manager
+
+import (
+	"bytes"
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"time"
+)
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_WithEndpointOptions_Ok() {
+	manager, err := New(
+		Bucket("bucket"),
+		Endpoint("http://localhost:9000"),
+		S3ForcePathStyle(true),
+		DisableSSL(true),
+		UseAccelerate(true),
+		UseDualStack(true),
+	)
+	assert.NoError(suite.T(), err)
+
+	m := manager.(*AwsManager)
+	assert.Equal(suite.T(), "http://localhost:9000", m.cfg.Endpoint)
+	assert.True(suite.T(), aws.BoolValue(m.cfg.S3ForcePathStyle))
+	assert.True(suite.T(), aws.BoolValue(m.cfg.DisableSSL))
+	assert.True(suite.T(), aws.BoolValue(m.cfg.UseAccelerate))
+	assert.True(suite.T(), aws.BoolValue(m.cfg.UseDualStack))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_WithEndpointEnvVariables_Ok() {
+	defer suite.setEnv("AWS_ENDPOINT", suite.s3Server.URL)()
+	defer suite.setEnv("AWS_S3_FORCE_PATH_STYLE", "true")()
+	defer suite.setEnv("AWS_BUCKET", standInBucket)()
+
+	manager, err := New()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.s3Server.URL, manager.(*AwsManager).cfg.Endpoint)
+
+	suite.s3Server.PutObject(standInBucket, fileName, &test.S3Object{Body: []byte("content")})
+
+	exists, err := manager.Exists(context.TODO(), fileName)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), exists)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_StandIn_RoundTrip_Ok() {
+	manager := suite.newStandInManager()
+	content, err := ioutil.ReadFile(filePath)
+	assert.NoError(suite.T(), err)
+
+	_, err = manager.Upload(context.TODO(), &UploadInput{
+		Path:        filePath,
+		FileName:    "docs/" + fileName,
+		ContentType: "application/pdf",
+		Metadata:    map[string]string{"Merchant": "merchant_id"},
+	})
+	assert.NoError(suite.T(), err)
+
+	info, err := manager.Stat(context.TODO(), &StatInput{FileName: "docs/" + fileName})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(len(content)), info.Size)
+	assert.Equal(suite.T(), "application/pdf", info.ContentType)
+	assert.Equal(suite.T(), "merchant_id", info.Metadata["Merchant"])
+
+	path := filepath.Join(suite.newDownloadDir(), fileName)
+	out, err := manager.Download(context.TODO(), path, &DownloadInput{FileName: "docs/" + fileName})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(len(content)), out.Size)
+	assert.Equal(suite.T(), info.ETag, out.ETag)
+
+	downloaded, err := ioutil.ReadFile(path)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), content, downloaded)
+
+	_, err = manager.Move(context.TODO(), &CopyInput{SourceFileName: "docs/" + fileName, FileName: "archive/" + fileName})
+	assert.NoError(suite.T(), err)
+
+	it := manager.List(context.TODO(), &ListInput{Delimiter: "/"})
+	prefixes := make([]string, 0)
+
+	for it.Next() {
+		prefixes = append(prefixes, it.CommonPrefix())
+	}
+
+	assert.NoError(suite.T(), it.Err())
+	assert.Equal(suite.T(), []string{"archive/"}, prefixes)
+
+	_, err = manager.Delete(context.TODO(), &DeleteInput{FileName: "archive/" + fileName})
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), suite.s3Server.Keys(standInBucket))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_StandIn_PresignGet_Ok() {
+	manager := suite.newStandInManager()
+	suite.s3Server.PutObject(standInBucket, fileName, &test.S3Object{Body: []byte("content")})
+
+	out, err := manager.PresignGet(fileName, time.Minute, nil)
+	assert.NoError(suite.T(), err)
+
+	u, err := url.Parse(out.URL)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "/"+standInBucket+"/"+fileName, u.Path)
+
+	rsp, err := http.Get(out.URL)
+	assert.NoError(suite.T(), err)
+	defer rsp.Body.Close()
+
+	body, err := ioutil.ReadAll(rsp.Body)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), bytes.Equal([]byte("content"), body))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_StandIn_PostPolicyURL_Ok() {
+	form, err := suite.newStandInManager().NewPostPolicy("uploads/", time.Minute).Sign()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.s3Server.URL+"/"+standInBucket+"/", form.URL)
+}
+
+func (suite *AwsManagerTestSuite) TestOptions_BucketURL() {
+	cases := []struct {
+		opts Options
+		url  string
+	}{
+		{Options{Region: "eu-west-1"}, "https://bucket.s3.eu-west-1.amazonaws.com/"},
+		{Options{Region: "eu-west-1", S3ForcePathStyle: aws.Bool(true)}, "https://s3.eu-west-1.amazonaws.com/bucket/"},
+		{Options{Region: "eu-west-1", DisableSSL: aws.Bool(true)}, "http://bucket.s3.eu-west-1.amazonaws.com/"},
+		{Options{Region: "eu-west-1", UseDualStack: aws.Bool(true)}, "https://bucket.s3.dualstack.eu-west-1.amazonaws.com/"},
+		{Options{Region: "eu-west-1", UseAccelerate: aws.Bool(true)}, "https://bucket.s3-accelerate.amazonaws.com/"},
+		{
+			Options{Region: "eu-west-1", UseAccelerate: aws.Bool(true), UseDualStack: aws.Bool(true)},
+			"https://bucket.s3-accelerate.dualstack.amazonaws.com/",
+		},
+		{Options{Endpoint: "http://localhost:9000", S3ForcePathStyle: aws.Bool(true)}, "http://localhost:9000/bucket/"},
+		{Options{Endpoint: "minio.local"}, "https://bucket.minio.local/"},
+		{Options{Endpoint: "minio.local", DisableSSL: aws.Bool(true)}, "http://bucket.minio.local/"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(suite.T(), c.url, c.opts.bucketURL("bucket"))
+	}
+}
@@ -0,0 +1,306 @@
+package aws_manager
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"net/http"
+	"strings"
+)
+
+const (
+	errCodeNotFound           = "NotFound"
+	errCodeNotModified        = "NotModified"
+	errCodePreconditionFailed = "PreconditionFailed"
+	errCodeAccessDenied       = "AccessDenied"
+	errCodeForbidden          = "Forbidden"
+	errCodeSlowDown           = "SlowDown"
+	errCodeInvalidArgument    = "InvalidArgument"
+	errCodeInvalidRequest     = "InvalidRequest"
+	errCodeBadDigest          = "BadDigest"
+)
+
+// The kinds of S3 failures. Manager methods return them wrapped in Error, use the Is helpers,
+// e.g. IsNotFound, to check the kind of an error.
+var (
+	ErrNotFound              = errors.New("object not found")
+	ErrBucketNotFound        = errors.New("bucket not found")
+	ErrAccessDenied          = errors.New("access denied")
+	ErrPreconditionFailed    = errors.New("precondition failed")
+	ErrNotModified           = errors.New("not modified")
+	ErrThrottled             = errors.New("request throttled")
+	ErrInvalidSSEKey         = errors.New("invalid customer provided encryption key")
+	ErrChecksumMismatch      = errors.New("checksum mismatch")
+	ErrContentTypeMismatch   = errors.New("content does not match the content type")
+	ErrContentTypeNotAllowed = errors.New("content type not allowed")
+	ErrUnsafeKey             = errors.New("object key escapes the local directory")
+	ErrIncompleteDownload    = errors.New("downloaded size does not match object content length")
+)
+
+// Error wraps the error of a failed S3 request with the operation and the object it was made for.
+// It implements awserr.RequestFailure, so the code, the status code and the request id
+// of the original error remain available.
+type Error struct {
+	Op        string
+	Bucket    string
+	Key       string
+	RequestId string
+	Kind      error
+	Err       error
+}
+
+func (e *Error) Error() string {
+	if e.Kind != nil {
+		return fmt.Sprintf("%s s3://%s/%s: %v: %v", e.Op, e.Bucket, e.Key, e.Kind, e.Err)
+	}
+
+	return fmt.Sprintf("%s s3://%s/%s: %v", e.Op, e.Bucket, e.Key, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the kind of the error.
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+func (e *Error) Code() string {
+	if aerr, ok := e.Err.(awserr.Error); ok {
+		return aerr.Code()
+	}
+
+	return ""
+}
+
+func (e *Error) Message() string {
+	if aerr, ok := e.Err.(awserr.Error); ok {
+		return aerr.Message()
+	}
+
+	return e.Err.Error()
+}
+
+func (e *Error) OrigErr() error {
+	return e.Err
+}
+
+func (e *Error) StatusCode() int {
+	if reqErr := findRequestFailure(e.Err); reqErr != nil {
+		return reqErr.StatusCode()
+	}
+
+	return 0
+}
+
+func (e *Error) RequestID() string {
+	return e.RequestId
+}
+
+func IsNotFound(err error) bool {
+	return errorKind(err) == ErrNotFound
+}
+
+func IsBucketNotFound(err error) bool {
+	return errorKind(err) == ErrBucketNotFound
+}
+
+func IsAccessDenied(err error) bool {
+	return errorKind(err) == ErrAccessDenied
+}
+
+func IsPreconditionFailed(err error) bool {
+	return errorKind(err) == ErrPreconditionFailed
+}
+
+func IsNotModified(err error) bool {
+	return errorKind(err) == ErrNotModified
+}
+
+func IsThrottled(err error) bool {
+	return errorKind(err) == ErrThrottled
+}
+
+func IsInvalidSSEKey(err error) bool {
+	return errorKind(err) == ErrInvalidSSEKey
+}
+
+func IsChecksumMismatch(err error) bool {
+	return errorKind(err) == ErrChecksumMismatch
+}
+
+func IsContentTypeMismatch(err error) bool {
+	return errorKind(err) == ErrContentTypeMismatch
+}
+
+func IsContentTypeNotAllowed(err error) bool {
+	return errorKind(err) == ErrContentTypeNotAllowed
+}
+
+func IsUnsafeKey(err error) bool {
+	return errorKind(err) == ErrUnsafeKey
+}
+
+func IsIncompleteDownload(err error) bool {
+	return errorKind(err) == ErrIncompleteDownload
+}
+
+// IsRetryable reports whether repeating the failed request may succeed: throttling, server errors,
+// timeouts, connection failures and incomplete downloads. Errors of other known kinds are not retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch errorKind(err) {
+	case nil:
+	case ErrThrottled, ErrIncompleteDownload:
+		return true
+	default:
+		return false
+	}
+
+	if e, ok := err.(*Error); ok {
+		err = e.Err
+	}
+
+	if _, ok := err.(awserr.Error); !ok {
+		return false
+	}
+
+	if reqErr := findRequestFailure(err); reqErr != nil && reqErr.StatusCode() >= http.StatusInternalServerError {
+		return true
+	}
+
+	return request.IsErrorRetryable(err)
+}
+
+// errorKind returns the kind of err, which may be a kind itself, Error or DeleteObjectError.
+func errorKind(err error) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *Error:
+		return e.Kind
+	case *DeleteObjectError:
+		return kindOf(e.Code, 0, e.Message, false)
+	}
+
+	for _, kind := range []error{
+		ErrNotFound, ErrBucketNotFound, ErrAccessDenied, ErrPreconditionFailed,
+		ErrNotModified, ErrThrottled, ErrInvalidSSEKey, ErrChecksumMismatch,
+		ErrContentTypeMismatch, ErrContentTypeNotAllowed, ErrUnsafeKey, ErrIncompleteDownload,
+	} {
+		if err == kind {
+			return kind
+		}
+	}
+
+	return nil
+}
+
+// wrapError wraps the errors of the SDK into Error, other errors are returned as is.
+// sseCustomerKey tells the request carried a customer provided key, as S3 rejects a wrong key
+// of a HEAD request without telling the reason.
+func wrapError(err error, op, bucket, key string, sseCustomerKey bool) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+
+	if _, ok := err.(awserr.Error); !ok {
+		return err
+	}
+
+	e := &Error{Op: op, Bucket: bucket, Key: key, Err: err}
+
+	if reqErr := findRequestFailure(err); reqErr != nil {
+		e.RequestId = reqErr.RequestID()
+	}
+
+	for cause := err; cause != nil && e.Kind == nil; {
+		aerr, ok := cause.(awserr.Error)
+
+		if !ok {
+			break
+		}
+
+		status := 0
+
+		if reqErr, ok := cause.(awserr.RequestFailure); ok {
+			status = reqErr.StatusCode()
+		}
+
+		e.Kind = kindOf(aerr.Code(), status, aerr.Message(), sseCustomerKey)
+		cause = aerr.OrigErr()
+	}
+
+	return e
+}
+
+func kindOf(code string, status int, message string, sseCustomerKey bool) error {
+	switch {
+	case code == s3.ErrCodeNoSuchBucket:
+		return ErrBucketNotFound
+	case code == s3.ErrCodeNoSuchKey || code == errCodeNotFound:
+		return ErrNotFound
+	case code == errCodePreconditionFailed || status == http.StatusPreconditionFailed:
+		return ErrPreconditionFailed
+	case code == errCodeNotModified || status == http.StatusNotModified:
+		return ErrNotModified
+	case code == errCodeBadDigest:
+		return ErrChecksumMismatch
+	case code == errCodeSlowDown || request.IsErrorThrottle(awserr.New(code, "", nil)) ||
+		status == http.StatusTooManyRequests:
+		return ErrThrottled
+	case isSSEKeyError(code, status, message, sseCustomerKey):
+		return ErrInvalidSSEKey
+	case code == errCodeAccessDenied || code == errCodeForbidden || status == http.StatusForbidden:
+		return ErrAccessDenied
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// isSSEKeyError falls back to the status only for responses without an error code, e.g. of HEAD requests,
+// for which the SDK derives the code from the status text.
+func isSSEKeyError(code string, status int, message string, sseCustomerKey bool) bool {
+	if sseCustomerKey && (status == http.StatusBadRequest || status == http.StatusForbidden) &&
+		(code == "" || code == strings.Replace(http.StatusText(status), " ", "", -1)) {
+		return true
+	}
+
+	switch code {
+	case errCodeInvalidArgument, errCodeInvalidRequest, errCodeAccessDenied:
+		message = strings.ToLower(message)
+		return strings.Contains(message, "sse-c") || strings.Contains(message, "encryption key")
+	}
+
+	return false
+}
+
+func findRequestFailure(err error) awserr.RequestFailure {
+	for err != nil {
+		if reqErr, ok := err.(awserr.RequestFailure); ok {
+			return reqErr
+		}
+
+		aerr, ok := err.(awserr.Error)
+
+		if !ok {
+			return nil
+		}
+
+		err = aerr.OrigErr()
+	}
+
+	return nil
+}
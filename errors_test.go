@@ -0,0 +1,167 @@
+package aws_manager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+)
+
+func (suite *AwsManagerTestSuite) TestWrapError_Kind() {
+	requestFailure := func(code string, status int) error {
+		return awserr.NewRequestFailure(awserr.New(code, code, nil), status, "RequestId")
+	}
+
+	cases := []struct {
+		err            error
+		sseCustomerKey bool
+		kind           error
+	}{
+		{requestFailure("NoSuchKey", http.StatusNotFound), false, ErrNotFound},
+		{requestFailure("NotFound", http.StatusNotFound), false, ErrNotFound},
+		{requestFailure("NoSuchBucket", http.StatusNotFound), false, ErrBucketNotFound},
+		{requestFailure("AccessDenied", http.StatusForbidden), false, ErrAccessDenied},
+		{requestFailure("Forbidden", http.StatusForbidden), false, ErrAccessDenied},
+		// HEAD responses have no body, the SDK derives the code from the status
+		{requestFailure("Forbidden", http.StatusForbidden), true, ErrInvalidSSEKey},
+		{requestFailure("BadRequest", http.StatusBadRequest), true, ErrInvalidSSEKey},
+		{requestFailure("AccessDenied", http.StatusForbidden), true, ErrAccessDenied},
+		{requestFailure("EntityTooLarge", http.StatusBadRequest), true, nil},
+		{
+			awserr.NewRequestFailure(
+				awserr.New("AccessDenied", "Requests specifying Server Side Encryption with Customer provided keys must provide the correct encryption key.", nil),
+				http.StatusForbidden,
+				"RequestId",
+			),
+			true,
+			ErrInvalidSSEKey,
+		},
+		{
+			awserr.NewRequestFailure(
+				awserr.New("InvalidArgument", "The calculated MD5 hash of the key did not match the hash that was provided.", nil),
+				http.StatusBadRequest,
+				"RequestId",
+			),
+			false,
+			nil,
+		},
+		{
+			awserr.NewRequestFailure(
+				awserr.New("InvalidRequest", "The object was stored using a form of SSE-C.", nil),
+				http.StatusBadRequest,
+				"RequestId",
+			),
+			false,
+			ErrInvalidSSEKey,
+		},
+		{requestFailure("PreconditionFailed", http.StatusPreconditionFailed), false, ErrPreconditionFailed},
+		{requestFailure("NotModified", http.StatusNotModified), false, ErrNotModified},
+		{requestFailure("BadDigest", http.StatusBadRequest), false, ErrChecksumMismatch},
+		{requestFailure("SlowDown", http.StatusServiceUnavailable), false, ErrThrottled},
+		{requestFailure("Throttling", http.StatusBadRequest), false, ErrThrottled},
+		{requestFailure("TooManyRequests", http.StatusTooManyRequests), false, ErrThrottled},
+		{requestFailure("InternalError", http.StatusInternalServerError), false, nil},
+		{
+			awserr.New("MultipartUpload", "upload multipart failed", requestFailure("AccessDenied", http.StatusForbidden)),
+			false,
+			ErrAccessDenied,
+		},
+	}
+
+	for _, c := range cases {
+		err := wrapError(c.err, "Upload", "bucket", "key", c.sseCustomerKey)
+		e, ok := err.(*Error)
+		assert.True(suite.T(), ok)
+		assert.Equal(suite.T(), c.kind, e.Kind, c.err.Error())
+		assert.Equal(suite.T(), "RequestId", e.RequestId)
+		assert.Equal(suite.T(), c.err, e.Unwrap())
+	}
+}
+
+func (suite *AwsManagerTestSuite) TestWrapError_NotAwsError() {
+	assert.Nil(suite.T(), wrapError(nil, "Upload", "bucket", "key", false))
+	assert.Equal(suite.T(), os.ErrNotExist, wrapError(os.ErrNotExist, "Upload", "bucket", "key", false))
+
+	err := wrapError(awserr.New("NotFound", "Not Found", nil), "Stat", "bucket", "key", false)
+	assert.Equal(suite.T(), err, wrapError(err, "Copy", "bucket", "other", false))
+	assert.Equal(suite.T(), "Stat s3://bucket/key: object not found: NotFound: Not Found", err.Error())
+}
+
+func (suite *AwsManagerTestSuite) TestError_AwsErrorCompatible() {
+	err := wrapError(
+		awserr.NewRequestFailure(awserr.New("AccessDenied", "Access Denied", nil), http.StatusForbidden, "RequestId"),
+		"Upload",
+		"bucket",
+		"key",
+		false,
+	)
+
+	reqErr, ok := err.(awserr.RequestFailure)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), "AccessDenied", reqErr.Code())
+	assert.Equal(suite.T(), "Access Denied", reqErr.Message())
+	assert.Equal(suite.T(), http.StatusForbidden, reqErr.StatusCode())
+	assert.Equal(suite.T(), "RequestId", reqErr.RequestID())
+	assert.True(suite.T(), err.(*Error).Is(ErrAccessDenied))
+	assert.False(suite.T(), err.(*Error).Is(ErrNotFound))
+}
+
+func (suite *AwsManagerTestSuite) TestIsRetryable() {
+	wrap := func(err error) error {
+		return wrapError(err, "Stat", "bucket", "key", false)
+	}
+
+	assert.True(suite.T(), IsRetryable(wrap(awserr.NewRequestFailure(awserr.New("SlowDown", "", nil), http.StatusServiceUnavailable, ""))))
+	assert.True(suite.T(), IsRetryable(wrap(awserr.NewRequestFailure(awserr.New("InternalError", "", nil), http.StatusInternalServerError, ""))))
+	assert.True(suite.T(), IsRetryable(wrap(awserr.New("RequestError", "send request failed", errors.New("connection reset by peer")))))
+	assert.True(suite.T(), IsRetryable(ErrThrottled))
+	assert.True(suite.T(), IsRetryable(&Error{Op: "Download", Kind: ErrIncompleteDownload, Err: errors.New("received 7 of 10 bytes")}))
+	assert.False(suite.T(), IsRetryable(wrap(awserr.NewRequestFailure(awserr.New("NoSuchKey", "", nil), http.StatusNotFound, ""))))
+	assert.False(suite.T(), IsRetryable(wrap(awserr.New(request.CanceledErrorCode, "canceled", context.Canceled))))
+	assert.False(suite.T(), IsRetryable(os.ErrNotExist))
+	assert.False(suite.T(), IsRetryable(nil))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_StandIn_ErrorKinds() {
+	manager := suite.newStandInManager(MaxRetries(0))
+	suite.s3Server.PutObject(standInBucket, fileName, &test.S3Object{Body: []byte("content")})
+
+	_, err := manager.Stat(context.TODO(), &StatInput{FileName: "missing.pdf"})
+	assert.True(suite.T(), IsNotFound(err))
+
+	_, _, err = manager.Open(context.TODO(), &DownloadInput{FileName: "missing.pdf"})
+	assert.True(suite.T(), IsNotFound(err))
+	assert.NotEmpty(suite.T(), err.(*Error).RequestId)
+	assert.Equal(suite.T(), "Open", err.(*Error).Op)
+
+	_, _, err = manager.Open(context.TODO(), &DownloadInput{FileName: fileName, IfMatch: `"other"`})
+	assert.True(suite.T(), IsPreconditionFailed(err))
+
+	info, err := manager.Stat(context.TODO(), &StatInput{FileName: fileName})
+	assert.NoError(suite.T(), err)
+
+	_, _, err = manager.Open(context.TODO(), &DownloadInput{FileName: fileName, IfNoneMatch: info.ETag})
+	assert.True(suite.T(), IsNotModified(err))
+
+	_, err = manager.Upload(context.TODO(), &UploadInput{Bucket: "missing-bucket", FileName: fileName, Body: bytes.NewReader([]byte("content"))})
+	assert.True(suite.T(), IsBucketNotFound(err))
+	assert.Equal(suite.T(), "Upload", err.(*Error).Op)
+	assert.Equal(suite.T(), "missing-bucket", err.(*Error).Bucket)
+
+	it := manager.List(context.TODO(), &ListInput{Bucket: "missing-bucket"})
+	assert.False(suite.T(), it.Next())
+	assert.True(suite.T(), IsBucketNotFound(it.Err()))
+
+	_, err = manager.Upload(context.TODO(), &UploadInput{Path: "./not_exist.pdf", FileName: fileName})
+	assert.True(suite.T(), os.IsNotExist(err))
+}
+
+func (suite *AwsManagerTestSuite) TestIsAccessDenied_DeleteObjectError() {
+	assert.True(suite.T(), IsAccessDenied(&DeleteObjectError{FileName: fileName, Code: "AccessDenied", Message: "Access Denied"}))
+	assert.False(suite.T(), IsNotFound(&DeleteObjectError{FileName: fileName, Code: "InternalError"}))
+}
@@ -0,0 +1,127 @@
+package aws_manager
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	etagPartSizeAlignment = 1024 * 1024
+	awsCliPartSize        = 8 * 1024 * 1024
+)
+
+// etagParts returns the number of parts of a multipart upload ETag such as "<md5>-3",
+// or 0 for the MD5 ETag of an object uploaded in one request.
+func etagParts(etag string) int {
+	etag = strings.Trim(etag, `"`)
+	i := strings.LastIndex(etag, "-")
+
+	if i < 0 {
+		return 0
+	}
+
+	n, err := strconv.Atoi(etag[i+1:])
+
+	if err != nil || n < 1 {
+		return 0
+	}
+
+	return n
+}
+
+// fileMatchesETag tells whether the local file has the content the object ETag was computed from.
+// A multipart ETag depends on the part size, which is not stored with the object, so the part size
+// of the manager, the defaults of the SDK and of the AWS CLI and the size derived from the number
+// of parts are tried. ETags of objects encrypted with SSE-KMS or SSE-C never match.
+func fileMatchesETag(path string, size int64, etag string, partSize int64) (bool, error) {
+	etag = strings.Trim(etag, `"`)
+	parts := etagParts(etag)
+
+	if parts == 0 {
+		sum, err := fileETag(path, 0)
+
+		if err != nil {
+			return false, err
+		}
+
+		return sum == etag, nil
+	}
+
+	derived := (size + int64(parts) - 1) / int64(parts)
+	derived = (derived + etagPartSizeAlignment - 1) / etagPartSizeAlignment * etagPartSizeAlignment
+	tried := make(map[int64]bool)
+
+	for _, candidate := range []int64{partSize, s3manager.DefaultUploadPartSize, awsCliPartSize, derived} {
+		if candidate <= 0 || tried[candidate] || (size+candidate-1)/candidate != int64(parts) {
+			continue
+		}
+
+		tried[candidate] = true
+		sum, err := fileETag(path, candidate)
+
+		if err != nil {
+			return false, err
+		}
+
+		if sum == etag {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// fileETag computes the ETag S3 assigns to the content of the file, uploaded in one request
+// when partSize is 0 or in parts of partSize bytes otherwise. The result is not quoted.
+func fileETag(path string, partSize int64) (string, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	return readerETag(file, partSize)
+}
+
+func readerETag(r io.Reader, partSize int64) (string, error) {
+	if partSize <= 0 {
+		h := md5.New()
+
+		if _, err := io.Copy(h, r); err != nil {
+			return "", err
+		}
+
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	sums := md5.New()
+	parts := 0
+
+	for {
+		h := md5.New()
+		n, err := io.CopyN(h, r, partSize)
+
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+
+		if n > 0 || parts == 0 {
+			sums.Write(h.Sum(nil))
+			parts++
+		}
+
+		if n < partSize {
+			break
+		}
+	}
+
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(sums.Sum(nil)), parts), nil
+}
@@ -3,8 +3,9 @@ module github.com/paysuper/paysuper-aws-manager
 go 1.12
 
 require (
-	github.com/aws/aws-sdk-go v1.23.8
+	github.com/aws/aws-sdk-go v1.24.0
 	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/stretchr/testify v1.4.0
 	github.com/vektra/mockery v0.0.0-20181123154057-e78b021dcbb5 // indirect
+	gopkg.in/yaml.v2 v2.2.2
 )
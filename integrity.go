@@ -0,0 +1,146 @@
+package aws_manager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"io"
+	"strings"
+)
+
+const (
+	// IntegritySHA256 and IntegrityMD5 tell in DownloadOutput.VerifiedWith which checksum a download was verified with.
+	IntegritySHA256 = "sha256"
+	IntegrityMD5    = "md5"
+
+	integrityMetadataKey = "sha256"
+)
+
+// integrityUploaderOption makes the SDK send the Content-MD5 of every PutObject and UploadPart body,
+// even when S3DisableContentMD5Validation is set, so S3 rejects a part corrupted on the way.
+func integrityUploaderOption(u *s3manager.Uploader) {
+	opts := make([]request.Option, 0, len(u.RequestOptions)+1)
+	opts = append(opts, u.RequestOptions...)
+	u.RequestOptions = append(opts, func(r *request.Request) {
+		r.Config.S3DisableContentMD5Validation = aws.Bool(false)
+	})
+}
+
+// addIntegrityMetadata stores the SHA-256 of the remaining content of a seekable body in the metadata.
+// Other bodies are left as is, as the metadata is sent before the body.
+func addIntegrityMetadata(in *s3manager.UploadInput) error {
+	body, ok := in.Body.(io.ReadSeeker)
+
+	if !ok {
+		return nil
+	}
+
+	sum, err := readSeekerSHA256(body)
+
+	if err != nil {
+		return err
+	}
+
+	metadata := make(map[string]*string, len(in.Metadata)+1)
+
+	for k, v := range in.Metadata {
+		metadata[k] = v
+	}
+
+	metadata[integrityMetadataKey] = aws.String(sum)
+	in.Metadata = metadata
+
+	return nil
+}
+
+// verifyIntegrity compares the content of r with the SHA-256 stored by an upload in the integrity mode.
+// Objects without the checksum are compared with the MD5 ETag when the object was uploaded in one request
+// and is not encrypted with SSE-KMS or SSE-C. The checksum used is set to out.VerifiedWith, which stays
+// empty when the object can't be verified.
+func verifyIntegrity(r io.ReadSeeker, out *DownloadOutput, bucket, key string) error {
+	algorithm, expected := IntegritySHA256, integrityChecksum(out.Metadata)
+
+	if expected == "" {
+		if !etagIsMD5(out) {
+			return nil
+		}
+
+		algorithm, expected = IntegrityMD5, strings.Trim(out.ETag, `"`)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	var (
+		actual string
+		err    error
+	)
+
+	if algorithm == IntegritySHA256 {
+		actual, err = readSeekerSHA256(r)
+	} else {
+		actual, err = readerETag(r, 0)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if actual == expected {
+		out.VerifiedWith = algorithm
+		return nil
+	}
+
+	return &Error{
+		Op:        "Download",
+		Bucket:    bucket,
+		Key:       key,
+		RequestId: out.RequestId,
+		Kind:      ErrChecksumMismatch,
+		Err:       fmt.Errorf("%s %s of the content does not match %s stored with the object", algorithm, actual, expected),
+	}
+}
+
+// etagIsMD5 tells whether the ETag of the object is the MD5 of its content, which holds for objects
+// uploaded in one request and not encrypted with SSE-KMS or SSE-C.
+func etagIsMD5(out *DownloadOutput) bool {
+	return out.ETag != "" && etagParts(out.ETag) == 0 && out.SSECustomerAlgorithm == "" &&
+		out.ServerSideEncryption != s3.ServerSideEncryptionAwsKms
+}
+
+// integrityChecksum returns the stored SHA-256, the SDK canonicalizes the case of the metadata keys.
+func integrityChecksum(metadata map[string]string) string {
+	for k, v := range metadata {
+		if strings.EqualFold(k, integrityMetadataKey) {
+			return strings.ToLower(v)
+		}
+	}
+
+	return ""
+}
+
+// readSeekerSHA256 hashes r from the current position to the end and seeks back to the position.
+func readSeekerSHA256(r io.ReadSeeker) (string, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+
+	if _, err = io.Copy(h, r); err != nil {
+		return "", err
+	}
+
+	if _, err = r.Seek(start, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
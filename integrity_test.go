@@ -0,0 +1,178 @@
+package aws_manager
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Integrity_Ok() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	manager := suite.newStandInManager(VerifyIntegrity(true), UploadPartSize(progressTestPartSize))
+	body := bytes.Repeat([]byte("integrity"), progressTestSize/9)
+	sum := sha256.Sum256(body)
+
+	_, err := manager.Upload(context.TODO(), &UploadInput{
+		Body:     bytes.NewReader(body),
+		FileName: fileName,
+		Metadata: map[string]string{"source": "export"},
+	})
+	assert.NoError(suite.T(), err)
+
+	object, ok := suite.s3Server.Object(standInBucket, fileName)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), 3, etagParts(object.ETag))
+	assert.Equal(suite.T(), hex.EncodeToString(sum[:]), object.Metadata["Sha256"])
+	assert.Equal(suite.T(), "export", object.Metadata["Source"])
+
+	path := filepath.Join(dir, fileName)
+	out, err := manager.Download(context.TODO(), path, &DownloadInput{FileName: fileName})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), IntegritySHA256, out.VerifiedWith)
+
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), body, content)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Download_ChecksumMismatch() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	_, err := suite.newStandInManager().Upload(context.TODO(), &UploadInput{
+		Path:            filePath,
+		FileName:        fileName,
+		VerifyIntegrity: true,
+	})
+	assert.NoError(suite.T(), err)
+
+	object, _ := suite.s3Server.Object(standInBucket, fileName)
+	suite.s3Server.PutObject(standInBucket, fileName, &test.S3Object{Body: []byte("corrupt"), Metadata: object.Metadata})
+
+	path := filepath.Join(dir, fileName)
+	_, err = suite.newStandInManager().Download(context.TODO(), path, &DownloadInput{FileName: fileName})
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.newStandInManager().Download(context.TODO(), path, &DownloadInput{FileName: fileName, VerifyIntegrity: true})
+	assert.True(suite.T(), IsChecksumMismatch(err))
+	assert.Equal(suite.T(), "Download", err.(*Error).Op)
+	assert.Equal(suite.T(), fileName, err.(*Error).Key)
+	assert.NotEmpty(suite.T(), err.(*Error).RequestId)
+	suite.assertNoTempFiles(dir)
+
+	path = filepath.Join(dir, "new.pdf")
+	_, err = suite.newStandInManager(VerifyIntegrity(true)).Download(context.TODO(), path, &DownloadInput{FileName: fileName})
+	assert.True(suite.T(), IsChecksumMismatch(err))
+
+	_, err = os.Stat(path)
+	assert.True(suite.T(), os.IsNotExist(err))
+
+	_, err = suite.newStandInManager(VerifyIntegrity(true)).Download(context.TODO(), path, &DownloadInput{
+		FileName: fileName,
+		Range:    "bytes=0-3",
+	})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Download_Integrity_ETag() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	manager := suite.newStandInManager(VerifyIntegrity(true))
+	_, err := manager.Upload(context.TODO(), &UploadInput{
+		Body:     ioutil.NopCloser(bytes.NewReader([]byte("content"))),
+		FileName: fileName,
+	})
+	assert.NoError(suite.T(), err)
+
+	object, _ := suite.s3Server.Object(standInBucket, fileName)
+	assert.Empty(suite.T(), integrityChecksum(object.Metadata))
+
+	path := filepath.Join(dir, fileName)
+	out, err := manager.Download(context.TODO(), path, &DownloadInput{FileName: fileName})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), IntegrityMD5, out.VerifiedWith)
+
+	suite.s3Server.PutObject(standInBucket, fileName, &test.S3Object{Body: []byte("corrupt"), ETag: object.ETag})
+	_, err = manager.Download(context.TODO(), filepath.Join(dir, "new.pdf"), &DownloadInput{FileName: fileName})
+	assert.True(suite.T(), IsChecksumMismatch(err))
+	suite.assertNoTempFiles(dir)
+
+	suite.s3Server.PutObject(standInBucket, fileName, &test.S3Object{Body: []byte("content"), ETag: `"etag-2"`})
+	out, err = manager.Download(context.TODO(), path, &DownloadInput{FileName: fileName})
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), out.VerifiedWith)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Upload_Integrity_ContentMD5() {
+	var (
+		mu      sync.Mutex
+		headers []string
+	)
+
+	record := func(u *s3manager.Uploader) {
+		u.RequestOptions = append(u.RequestOptions, func(r *request.Request) {
+			r.Config.S3DisableContentMD5Validation = aws.Bool(true)
+			r.Handlers.Send.PushFront(func(r *request.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				headers = append(headers, r.HTTPRequest.Header.Get("Content-Md5"))
+			})
+		})
+	}
+
+	manager := suite.newStandInManager(UploaderOptions(record), UploadPartSize(progressTestPartSize))
+	body := bytes.NewReader(make([]byte, progressTestSize))
+
+	_, err := manager.Upload(context.TODO(), &UploadInput{Body: body, FileName: fileName})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"", "", "", "", ""}, headers)
+
+	headers = nil
+	_, err = body.Seek(0, 0)
+	assert.NoError(suite.T(), err)
+
+	_, err = manager.Upload(context.TODO(), &UploadInput{Body: body, FileName: fileName, VerifyIntegrity: true})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), headers, 5)
+
+	var parts int
+
+	for _, v := range headers {
+		if v != "" {
+			parts++
+		}
+	}
+
+	assert.Equal(suite.T(), 3, parts)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Upload_BadDigest() {
+	_, err := suite.newStandInManager(MaxRetries(0)).Upload(context.TODO(), &UploadInput{
+		Body:     bytes.NewReader([]byte("content")),
+		FileName: fileName,
+	}, func(u *s3manager.Uploader) {
+		u.RequestOptions = append(u.RequestOptions, func(r *request.Request) {
+			r.Handlers.Build.PushBack(func(r *request.Request) {
+				r.HTTPRequest.Header.Set("Content-Md5", "1B2M2Y8AsgTpgAmY7PhCfg==")
+			})
+		})
+	})
+	assert.True(suite.T(), IsChecksumMismatch(err))
+
+	_, ok := suite.s3Server.Object(standInBucket, fileName)
+	assert.False(suite.T(), ok)
+}
@@ -0,0 +1,162 @@
+package aws_manager
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+type ListInput struct {
+	Bucket       string
+	Delimiter    string
+	MaxKeys      int64
+	Prefix       string
+	RequestPayer string
+	StartAfter   string
+}
+
+// ListIterator walks the objects and common prefixes returned by ListObjectsV2,
+// requesting the next page only when the current one is exhausted.
+type ListIterator struct {
+	ctx     context.Context
+	client  s3iface.S3API
+	in      *s3.ListObjectsV2Input
+	entries []*listEntry
+	current *listEntry
+	done    bool
+	err     error
+}
+
+type listEntry struct {
+	object *ObjectInfo
+	prefix string
+}
+
+func (m *AwsManager) List(ctx context.Context, in *ListInput) *ListIterator {
+	if in.Bucket == "" {
+		in.Bucket = m.cfg.Bucket
+	}
+
+	return &ListIterator{
+		ctx:    ctx,
+		client: m.awsS3,
+		in:     in.toAwsListObjectsV2Input(),
+	}
+}
+
+func (it *ListIterator) Next() bool {
+	for {
+		if it.err != nil {
+			return false
+		}
+
+		if len(it.entries) > 0 {
+			it.current = it.entries[0]
+			it.entries = it.entries[1:]
+			return true
+		}
+
+		it.current = nil
+
+		if it.done {
+			return false
+		}
+
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+
+		it.fetch()
+	}
+}
+
+// Object returns the current object or nil when the current entry is a common prefix.
+func (it *ListIterator) Object() *ObjectInfo {
+	if it.current == nil {
+		return nil
+	}
+
+	return it.current.object
+}
+
+// CommonPrefix returns the current common prefix or an empty string when the current entry is an object.
+func (it *ListIterator) CommonPrefix() string {
+	if it.current == nil {
+		return ""
+	}
+
+	return it.current.prefix
+}
+
+func (it *ListIterator) Err() error {
+	return it.err
+}
+
+func (it *ListIterator) fetch() {
+	out, err := it.client.ListObjectsV2WithContext(it.ctx, it.in)
+
+	if err != nil {
+		it.err = wrapError(err, "List", aws.StringValue(it.in.Bucket), aws.StringValue(it.in.Prefix), false)
+		return
+	}
+
+	bucket := aws.StringValue(it.in.Bucket)
+
+	for _, v := range out.Contents {
+		it.entries = append(it.entries, &listEntry{object: newObjectInfoFromListObject(bucket, v)})
+	}
+
+	for _, v := range out.CommonPrefixes {
+		it.entries = append(it.entries, &listEntry{prefix: aws.StringValue(v.Prefix)})
+	}
+
+	if !aws.BoolValue(out.IsTruncated) || aws.StringValue(out.NextContinuationToken) == "" {
+		it.done = true
+		return
+	}
+
+	it.in.ContinuationToken = out.NextContinuationToken
+}
+
+func (m *ListInput) toAwsListObjectsV2Input() *s3.ListObjectsV2Input {
+	out := &s3.ListObjectsV2Input{}
+
+	if m.Bucket != "" {
+		out.Bucket = aws.String(m.Bucket)
+	}
+
+	if m.Delimiter != "" {
+		out.Delimiter = aws.String(m.Delimiter)
+	}
+
+	if m.MaxKeys > 0 {
+		out.MaxKeys = aws.Int64(m.MaxKeys)
+	}
+
+	if m.Prefix != "" {
+		out.Prefix = aws.String(m.Prefix)
+	}
+
+	if m.RequestPayer != "" {
+		out.RequestPayer = aws.String(m.RequestPayer)
+	}
+
+	if m.StartAfter != "" {
+		out.StartAfter = aws.String(m.StartAfter)
+	}
+
+	return out
+}
+
+func newObjectInfoFromListObject(bucket string, in *s3.Object) *ObjectInfo {
+	return &ObjectInfo{
+		Bucket:       bucket,
+		FileName:     aws.StringValue(in.Key),
+		Size:         aws.Int64Value(in.Size),
+		ETag:         aws.StringValue(in.ETag),
+		LastModified: aws.TimeValue(in.LastModified),
+		StorageClass: aws.StringValue(in.StorageClass),
+	}
+}
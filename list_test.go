@@ -0,0 +1,122 @@
+package aws_manager
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (suite *AwsManagerTestSuite) TestAwsManager_List_FollowsContinuationTokens_Ok() {
+	mockS3 := &test.S3API{}
+	mockS3.On("ListObjectsV2WithContext", mock.Anything, mock.MatchedBy(func(in *s3.ListObjectsV2Input) bool {
+		return in.ContinuationToken == nil
+	})).Return(
+		&s3.ListObjectsV2Output{
+			Contents: []*s3.Object{
+				{Key: aws.String("merchant/1/a.pdf"), Size: aws.Int64(10), ETag: aws.String(`"etag-a"`)},
+				{Key: aws.String("merchant/1/b.pdf"), Size: aws.Int64(20)},
+			},
+			CommonPrefixes:        []*s3.CommonPrefix{{Prefix: aws.String("merchant/1/reports/")}},
+			IsTruncated:           aws.Bool(true),
+			NextContinuationToken: aws.String("token-1"),
+		},
+		nil,
+	).Once()
+	mockS3.On("ListObjectsV2WithContext", mock.Anything, mock.MatchedBy(func(in *s3.ListObjectsV2Input) bool {
+		return aws.StringValue(in.ContinuationToken) == "token-1"
+	})).Return(
+		&s3.ListObjectsV2Output{
+			Contents:    []*s3.Object{{Key: aws.String("merchant/1/c.pdf"), Size: aws.Int64(30)}},
+			IsTruncated: aws.Bool(false),
+		},
+		nil,
+	).Once()
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	in := &ListInput{
+		Prefix:     "merchant/1/",
+		Delimiter:  "/",
+		StartAfter: "merchant/1/0.pdf",
+		MaxKeys:    2,
+	}
+	it := suite.awsManager.List(context.TODO(), in)
+
+	var keys, prefixes []string
+
+	for it.Next() {
+		if obj := it.Object(); obj != nil {
+			assert.Equal(suite.T(), suite.awsManager.(*AwsManager).cfg.Bucket, obj.Bucket)
+			keys = append(keys, obj.FileName)
+			continue
+		}
+
+		prefixes = append(prefixes, it.CommonPrefix())
+	}
+
+	assert.NoError(suite.T(), it.Err())
+	assert.Equal(suite.T(), []string{"merchant/1/a.pdf", "merchant/1/b.pdf", "merchant/1/c.pdf"}, keys)
+	assert.Equal(suite.T(), []string{"merchant/1/reports/"}, prefixes)
+	assert.False(suite.T(), it.Next())
+	mockS3.AssertNumberOfCalls(suite.T(), "ListObjectsV2WithContext", 2)
+
+	s3In := mockS3.Calls[0].Arguments.Get(1).(*s3.ListObjectsV2Input)
+	assert.Equal(suite.T(), "merchant/1/", aws.StringValue(s3In.Prefix))
+	assert.Equal(suite.T(), "/", aws.StringValue(s3In.Delimiter))
+	assert.Equal(suite.T(), "merchant/1/0.pdf", aws.StringValue(s3In.StartAfter))
+	assert.Equal(suite.T(), int64(2), aws.Int64Value(s3In.MaxKeys))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_List_Empty_Ok() {
+	mockS3 := &test.S3API{}
+	mockS3.On("ListObjectsV2WithContext", mock.Anything, mock.Anything).
+		Return(&s3.ListObjectsV2Output{IsTruncated: aws.Bool(false)}, nil)
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	it := suite.awsManager.List(context.TODO(), &ListInput{Bucket: "bucket-name"})
+	assert.False(suite.T(), it.Next())
+	assert.NoError(suite.T(), it.Err())
+	assert.Nil(suite.T(), it.Object())
+	assert.Empty(suite.T(), it.CommonPrefix())
+
+	s3In := mockS3.Calls[0].Arguments.Get(1).(*s3.ListObjectsV2Input)
+	assert.Equal(suite.T(), "bucket-name", aws.StringValue(s3In.Bucket))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_List_RequestError() {
+	mockS3 := &test.S3API{}
+	mockS3.On("ListObjectsV2WithContext", mock.Anything, mock.Anything).
+		Return(nil, errors.New("request failed"))
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	it := suite.awsManager.List(context.TODO(), &ListInput{})
+	assert.False(suite.T(), it.Next())
+	assert.Error(suite.T(), it.Err())
+	assert.Regexp(suite.T(), "request failed", it.Err().Error())
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_List_ContextCanceled_Error() {
+	mockS3 := &test.S3API{}
+	mockS3.On("ListObjectsV2WithContext", mock.Anything, mock.Anything).
+		Return(
+			&s3.ListObjectsV2Output{
+				Contents:              []*s3.Object{{Key: aws.String("a.pdf")}},
+				IsTruncated:           aws.Bool(true),
+				NextContinuationToken: aws.String("token"),
+			},
+			nil,
+		)
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it := suite.awsManager.List(ctx, &ListInput{})
+	assert.True(suite.T(), it.Next())
+	cancel()
+
+	assert.False(suite.T(), it.Next())
+	assert.Equal(suite.T(), context.Canceled, it.Err())
+	mockS3.AssertNumberOfCalls(suite.T(), "ListObjectsV2WithContext", 1)
+}
@@ -4,16 +4,88 @@ package mocks
 
 import aws_manager "github.com/paysuper/paysuper-aws-manager"
 import context "context"
+import io "io"
 import mock "github.com/stretchr/testify/mock"
+import s3 "github.com/aws/aws-sdk-go/service/s3"
 import s3manager "github.com/aws/aws-sdk-go/service/s3/s3manager"
+import time "time"
 
 // AwsManagerInterface is an autogenerated mock type for the AwsManagerInterface type
 type AwsManagerInterface struct {
 	mock.Mock
 }
 
+// Copy provides a mock function with given fields: _a0, _a1
+func (_m *AwsManagerInterface) Copy(_a0 context.Context, _a1 *aws_manager.CopyInput) (*aws_manager.CopyOutput, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *aws_manager.CopyOutput
+	if rf, ok := ret.Get(0).(func(context.Context, *aws_manager.CopyInput) *aws_manager.CopyOutput); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*aws_manager.CopyOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *aws_manager.CopyInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: _a0, _a1
+func (_m *AwsManagerInterface) Delete(_a0 context.Context, _a1 *aws_manager.DeleteInput) (*s3.DeleteObjectOutput, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *s3.DeleteObjectOutput
+	if rf, ok := ret.Get(0).(func(context.Context, *aws_manager.DeleteInput) *s3.DeleteObjectOutput); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*s3.DeleteObjectOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *aws_manager.DeleteInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteMany provides a mock function with given fields: _a0, _a1
+func (_m *AwsManagerInterface) DeleteMany(_a0 context.Context, _a1 *aws_manager.DeleteManyInput) (*aws_manager.DeleteManyOutput, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *aws_manager.DeleteManyOutput
+	if rf, ok := ret.Get(0).(func(context.Context, *aws_manager.DeleteManyInput) *aws_manager.DeleteManyOutput); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*aws_manager.DeleteManyOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *aws_manager.DeleteManyInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Download provides a mock function with given fields: _a0, _a1, _a2, _a3
-func (_m *AwsManagerInterface) Download(_a0 context.Context, _a1 string, _a2 *aws_manager.DownloadInput, _a3 ...func(*s3manager.Downloader)) (int64, error) {
+func (_m *AwsManagerInterface) Download(_a0 context.Context, _a1 string, _a2 *aws_manager.DownloadInput, _a3 ...func(*s3manager.Downloader)) (*aws_manager.DownloadOutput, error) {
 	_va := make([]interface{}, len(_a3))
 	for _i := range _a3 {
 		_va[_i] = _a3[_i]
@@ -23,11 +95,13 @@ func (_m *AwsManagerInterface) Download(_a0 context.Context, _a1 string, _a2 *aw
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 int64
-	if rf, ok := ret.Get(0).(func(context.Context, string, *aws_manager.DownloadInput, ...func(*s3manager.Downloader)) int64); ok {
+	var r0 *aws_manager.DownloadOutput
+	if rf, ok := ret.Get(0).(func(context.Context, string, *aws_manager.DownloadInput, ...func(*s3manager.Downloader)) *aws_manager.DownloadOutput); ok {
 		r0 = rf(_a0, _a1, _a2, _a3...)
 	} else {
-		r0 = ret.Get(0).(int64)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*aws_manager.DownloadOutput)
+		}
 	}
 
 	var r1 error
@@ -40,6 +114,259 @@ func (_m *AwsManagerInterface) Download(_a0 context.Context, _a1 string, _a2 *aw
 	return r0, r1
 }
 
+// DownloadPrefix provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *AwsManagerInterface) DownloadPrefix(_a0 context.Context, _a1 string, _a2 string, _a3 *aws_manager.DownloadPrefixInput) (*aws_manager.DownloadPrefixOutput, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 *aws_manager.DownloadPrefixOutput
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *aws_manager.DownloadPrefixInput) *aws_manager.DownloadPrefixOutput); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*aws_manager.DownloadPrefixOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *aws_manager.DownloadPrefixInput) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DownloadTo provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *AwsManagerInterface) DownloadTo(_a0 context.Context, _a1 io.WriterAt, _a2 *aws_manager.DownloadInput, _a3 ...func(*s3manager.Downloader)) (*aws_manager.DownloadOutput, error) {
+	_va := make([]interface{}, len(_a3))
+	for _i := range _a3 {
+		_va[_i] = _a3[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, _a1, _a2)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *aws_manager.DownloadOutput
+	if rf, ok := ret.Get(0).(func(context.Context, io.WriterAt, *aws_manager.DownloadInput, ...func(*s3manager.Downloader)) *aws_manager.DownloadOutput); ok {
+		r0 = rf(_a0, _a1, _a2, _a3...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*aws_manager.DownloadOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, io.WriterAt, *aws_manager.DownloadInput, ...func(*s3manager.Downloader)) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Exists provides a mock function with given fields: _a0, _a1
+func (_m *AwsManagerInterface) Exists(_a0 context.Context, _a1 string) (bool, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: _a0, _a1
+func (_m *AwsManagerInterface) List(_a0 context.Context, _a1 *aws_manager.ListInput) *aws_manager.ListIterator {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *aws_manager.ListIterator
+	if rf, ok := ret.Get(0).(func(context.Context, *aws_manager.ListInput) *aws_manager.ListIterator); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*aws_manager.ListIterator)
+		}
+	}
+
+	return r0
+}
+
+// Move provides a mock function with given fields: _a0, _a1
+func (_m *AwsManagerInterface) Move(_a0 context.Context, _a1 *aws_manager.CopyInput) (*aws_manager.CopyOutput, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *aws_manager.CopyOutput
+	if rf, ok := ret.Get(0).(func(context.Context, *aws_manager.CopyInput) *aws_manager.CopyOutput); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*aws_manager.CopyOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *aws_manager.CopyInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewPostPolicy provides a mock function with given fields: _a0, _a1
+func (_m *AwsManagerInterface) NewPostPolicy(_a0 string, _a1 time.Duration) *aws_manager.PostPolicy {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *aws_manager.PostPolicy
+	if rf, ok := ret.Get(0).(func(string, time.Duration) *aws_manager.PostPolicy); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*aws_manager.PostPolicy)
+		}
+	}
+
+	return r0
+}
+
+// Open provides a mock function with given fields: _a0, _a1
+func (_m *AwsManagerInterface) Open(_a0 context.Context, _a1 *aws_manager.DownloadInput) (io.ReadCloser, *aws_manager.ObjectInfo, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(context.Context, *aws_manager.DownloadInput) io.ReadCloser); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 *aws_manager.ObjectInfo
+	if rf, ok := ret.Get(1).(func(context.Context, *aws_manager.DownloadInput) *aws_manager.ObjectInfo); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*aws_manager.ObjectInfo)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *aws_manager.DownloadInput) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// PresignGet provides a mock function with given fields: _a0, _a1, _a2
+func (_m *AwsManagerInterface) PresignGet(_a0 string, _a1 time.Duration, _a2 *aws_manager.DownloadInput) (*aws_manager.PresignOutput, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *aws_manager.PresignOutput
+	if rf, ok := ret.Get(0).(func(string, time.Duration, *aws_manager.DownloadInput) *aws_manager.PresignOutput); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*aws_manager.PresignOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, time.Duration, *aws_manager.DownloadInput) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PresignPut provides a mock function with given fields: _a0, _a1, _a2
+func (_m *AwsManagerInterface) PresignPut(_a0 string, _a1 time.Duration, _a2 *aws_manager.UploadInput) (*aws_manager.PresignOutput, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *aws_manager.PresignOutput
+	if rf, ok := ret.Get(0).(func(string, time.Duration, *aws_manager.UploadInput) *aws_manager.PresignOutput); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*aws_manager.PresignOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, time.Duration, *aws_manager.UploadInput) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Stat provides a mock function with given fields: _a0, _a1
+func (_m *AwsManagerInterface) Stat(_a0 context.Context, _a1 *aws_manager.StatInput) (*aws_manager.ObjectInfo, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *aws_manager.ObjectInfo
+	if rf, ok := ret.Get(0).(func(context.Context, *aws_manager.StatInput) *aws_manager.ObjectInfo); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*aws_manager.ObjectInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *aws_manager.StatInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Sync provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *AwsManagerInterface) Sync(_a0 context.Context, _a1 string, _a2 string, _a3 *aws_manager.SyncInput) (*aws_manager.SyncOutput, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 *aws_manager.SyncOutput
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *aws_manager.SyncInput) *aws_manager.SyncOutput); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*aws_manager.SyncOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *aws_manager.SyncInput) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Upload provides a mock function with given fields: _a0, _a1, _a2
 func (_m *AwsManagerInterface) Upload(_a0 context.Context, _a1 *aws_manager.UploadInput, _a2 ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
 	_va := make([]interface{}, len(_a2))
@@ -69,3 +396,26 @@ func (_m *AwsManagerInterface) Upload(_a0 context.Context, _a1 *aws_manager.Uplo
 
 	return r0, r1
 }
+
+// UploadDir provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *AwsManagerInterface) UploadDir(_a0 context.Context, _a1 string, _a2 string, _a3 *aws_manager.UploadDirInput) (*aws_manager.UploadDirOutput, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 *aws_manager.UploadDirOutput
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *aws_manager.UploadDirInput) *aws_manager.UploadDirOutput); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*aws_manager.UploadDirOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *aws_manager.UploadDirInput) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,48 @@
+package aws_manager
+
+import (
+	"context"
+	"sync"
+)
+
+// runPool calls fn for every index from 0 to n-1 using up to concurrency goroutines.
+// With failFast the context passed to fn is canceled after the first error, which is returned,
+// fn is still called for the remaining indexes so it can record the cancellation.
+func runPool(ctx context.Context, concurrency, n int, failFast bool, fn func(context.Context, int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	jobs := make(chan int)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for job := range jobs {
+				if err := fn(ctx, job); err != nil && failFast {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return firstErr
+}
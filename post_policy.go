@@ -0,0 +1,184 @@
+package aws_manager
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"strconv"
+	"time"
+)
+
+const (
+	postPolicyAlgorithm      = "AWS4-HMAC-SHA256"
+	postPolicyService        = "s3"
+	postPolicyDateFormat     = "20060102"
+	postPolicyDateTimeFormat = "20060102T150405Z"
+	postPolicyExpireFormat   = "2006-01-02T15:04:05.000Z"
+)
+
+// PostPolicy builds the form of a browser based upload (HTTP POST) signed with Signature Version 4.
+type PostPolicy struct {
+	bucket      string
+	region      string
+	bucketURL   func(string) string
+	credentials *credentials.Credentials
+	keyPrefix   string
+	ttl         time.Duration
+	conditions  []interface{}
+	fields      map[string]string
+	now         func() time.Time
+}
+
+type PostPolicyForm struct {
+	URL     string
+	Fields  map[string]string
+	Expires time.Time
+}
+
+type postPolicyDocument struct {
+	Expiration string        `json:"expiration"`
+	Conditions []interface{} `json:"conditions"`
+}
+
+// NewPostPolicy starts a policy which allows to upload objects with keys starting with keyPrefix
+// until ttl expires. The browser substitutes ${filename} in the key with the name of the uploaded file.
+func (m *AwsManager) NewPostPolicy(keyPrefix string, ttl time.Duration) *PostPolicy {
+	return &PostPolicy{
+		bucket:      m.cfg.Bucket,
+		region:      m.cfg.Region,
+		bucketURL:   m.cfg.bucketURL,
+		credentials: m.credentials,
+		keyPrefix:   keyPrefix,
+		ttl:         ttl,
+		fields:      map[string]string{"key": keyPrefix + "${filename}"},
+		now:         time.Now,
+	}
+}
+
+func (p *PostPolicy) Bucket(bucket string) *PostPolicy {
+	p.bucket = bucket
+	return p
+}
+
+func (p *PostPolicy) ContentLengthRange(min, max int64) *PostPolicy {
+	p.conditions = append(p.conditions, []interface{}{"content-length-range", min, max})
+	return p
+}
+
+func (p *PostPolicy) ContentType(contentType string) *PostPolicy {
+	return p.exact("Content-Type", contentType)
+}
+
+func (p *PostPolicy) ContentTypePrefix(prefix string) *PostPolicy {
+	p.conditions = append(p.conditions, []interface{}{"starts-with", "$Content-Type", prefix})
+	return p
+}
+
+func (p *PostPolicy) ACL(acl string) *PostPolicy {
+	return p.exact("acl", acl)
+}
+
+func (p *PostPolicy) Metadata(key, value string) *PostPolicy {
+	return p.exact("x-amz-meta-"+key, value)
+}
+
+func (p *PostPolicy) ServerSideEncryption(sse string) *PostPolicy {
+	return p.exact("x-amz-server-side-encryption", sse)
+}
+
+func (p *PostPolicy) SuccessActionStatus(status int) *PostPolicy {
+	return p.exact("success_action_status", strconv.Itoa(status))
+}
+
+// Sign resolves the manager credentials and returns the URL and the form fields
+// which must be posted together with the file.
+func (p *PostPolicy) Sign() (*PostPolicyForm, error) {
+	creds, err := p.credentials.Get()
+
+	if err != nil {
+		return nil, wrapError(err, "PostPolicy", p.bucket, p.keyPrefix, false)
+	}
+
+	now := p.now().UTC()
+	date := now.Format(postPolicyDateFormat)
+	credential := fmt.Sprintf("%s/%s/%s/%s/aws4_request", creds.AccessKeyID, date, p.region, postPolicyService)
+
+	fields := map[string]string{
+		"x-amz-algorithm":  postPolicyAlgorithm,
+		"x-amz-credential": credential,
+		"x-amz-date":       now.Format(postPolicyDateTimeFormat),
+	}
+
+	if creds.SessionToken != "" {
+		fields["x-amz-security-token"] = creds.SessionToken
+	}
+
+	conditions := []interface{}{
+		map[string]string{"bucket": p.bucket},
+		[]interface{}{"starts-with", "$key", p.keyPrefix},
+	}
+
+	for _, k := range []string{"x-amz-algorithm", "x-amz-credential", "x-amz-date", "x-amz-security-token"} {
+		if v, ok := fields[k]; ok {
+			conditions = append(conditions, map[string]string{k: v})
+		}
+	}
+
+	expires := now.Add(p.ttl)
+	document := &postPolicyDocument{
+		Expiration: expires.Format(postPolicyExpireFormat),
+		Conditions: append(conditions, p.conditions...),
+	}
+	b, err := json.Marshal(document)
+
+	if err != nil {
+		return nil, err
+	}
+
+	policy := base64.StdEncoding.EncodeToString(b)
+
+	for k, v := range p.fields {
+		fields[k] = v
+	}
+
+	fields["policy"] = policy
+	fields["x-amz-signature"] = postPolicySignature(creds.SecretAccessKey, date, p.region, policy)
+
+	form := &PostPolicyForm{
+		URL:     p.bucketURL(p.bucket),
+		Fields:  fields,
+		Expires: expires,
+	}
+
+	return form, nil
+}
+
+func (p *PostPolicy) exact(field, value string) *PostPolicy {
+	p.conditions = append(p.conditions, map[string]string{field: value})
+	p.fields[field] = value
+	return p
+}
+
+func postPolicySignature(secretAccessKey, date, region, policy string) string {
+	key := signingKey(secretAccessKey, date, region, postPolicyService)
+	return hex.EncodeToString(hmacSHA256(key, policy))
+}
+
+func signingKey(secretAccessKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
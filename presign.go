@@ -0,0 +1,97 @@
+package aws_manager
+
+import (
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"net/http"
+	"time"
+)
+
+type PresignOutput struct {
+	URL     string
+	Header  http.Header
+	Expires time.Time
+}
+
+// PresignGet returns a URL that allows to download the object without credentials until ttl expires.
+// Response header overrides and SSE-C fields of the input are included into the signature.
+func (m *AwsManager) PresignGet(key string, ttl time.Duration, in *DownloadInput) (*PresignOutput, error) {
+	getIn := DownloadInput{}
+
+	if in != nil {
+		getIn = *in
+	}
+
+	getIn.FileName = key
+
+	if getIn.Bucket == "" {
+		getIn.Bucket = m.cfg.Bucket
+	}
+
+	req, _ := m.awsS3.GetObjectRequest(getIn.toAwsGetObjectInput())
+	out, err := presign(req, ttl)
+
+	if err != nil {
+		return nil, wrapError(err, "PresignGet", getIn.Bucket, key, false)
+	}
+
+	return out, nil
+}
+
+// PresignPut returns a URL that allows to upload the object without credentials until ttl expires.
+// The returned headers are signed too and must be sent with the upload request as is.
+func (m *AwsManager) PresignPut(key string, ttl time.Duration, in *UploadInput) (*PresignOutput, error) {
+	putIn := UploadInput{}
+
+	if in != nil {
+		putIn = *in
+	}
+
+	putIn.FileName = key
+	putIn.Body = nil
+
+	if putIn.Bucket == "" {
+		putIn.Bucket = m.cfg.Bucket
+	}
+
+	m.cfg.Defaults.apply(&putIn)
+
+	req, _ := m.awsS3.PutObjectRequest(putIn.toAwsPutObjectInput())
+	out, err := presign(req, ttl)
+
+	if err != nil {
+		return nil, wrapError(err, "PresignPut", putIn.Bucket, key, false)
+	}
+
+	return out, nil
+}
+
+func (m *UploadInput) toAwsPutObjectInput() *s3.PutObjectInput {
+	out := &s3.PutObjectInput{}
+	awsutil.Copy(out, m.toAwsUploadInput())
+
+	return out
+}
+
+func presign(req *request.Request, ttl time.Duration) (*PresignOutput, error) {
+	url, header, err := req.PresignRequest(ttl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out := &PresignOutput{
+		URL:     url,
+		Header:  http.Header{},
+		Expires: time.Now().Add(ttl),
+	}
+
+	for k, v := range header {
+		for _, hv := range v {
+			out.Header.Add(k, hv)
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,286 @@
+package aws_manager
+
+import (
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProgressInterval = 200 * time.Millisecond
+
+	progressOpUploadPart = "UploadPart"
+	progressOpPutObject  = "PutObject"
+	progressOpGetObject  = "GetObject"
+)
+
+// Progress is a snapshot of a transfer. Total is -1 while the size is unknown.
+type Progress struct {
+	Bytes          int64
+	Total          int64
+	PartsCompleted int
+	Elapsed        time.Duration
+	Throughput     float64
+	Done           bool
+}
+
+// ProgressListener receives the progress of a transfer. Calls are sequential and made
+// by the goroutines of the transfer, so the listener must return quickly.
+type ProgressListener func(Progress)
+
+// ProgressChan returns a listener which sends the progress into ch without blocking the transfer.
+// ch must be buffered, when it is full the unread progress is replaced by the latest one,
+// so the final progress with Done set is never lost. The channel is not closed.
+func ProgressChan(ch chan Progress) ProgressListener {
+	return func(p Progress) {
+		select {
+		case ch <- p:
+			return
+		default:
+		}
+
+		select {
+		case <-ch:
+		default:
+		}
+
+		select {
+		case ch <- p:
+		default:
+		}
+	}
+}
+
+// progressTracker counts the transferred bytes and completed parts and calls the listener
+// at most once per interval, except for the final progress. A nil tracker ignores all calls.
+type progressTracker struct {
+	mu       sync.Mutex
+	listener ProgressListener
+	interval time.Duration
+	start    time.Time
+	last     time.Time
+	bytes    int64
+	total    int64
+	totalFn  func() (int64, bool)
+	parts    int
+	done     bool
+}
+
+type progressReader struct {
+	io.Reader
+	tracker *progressTracker
+}
+
+type progressReadCloser struct {
+	io.ReadCloser
+	tracker *progressTracker
+}
+
+type progressWriterAt struct {
+	io.WriterAt
+	tracker *progressTracker
+}
+
+func newProgressTracker(listener ProgressListener, interval time.Duration, total int64) *progressTracker {
+	if listener == nil {
+		return nil
+	}
+
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+
+	now := time.Now()
+
+	return &progressTracker{
+		listener: listener,
+		interval: interval,
+		start:    now,
+		last:     now,
+		total:    total,
+	}
+}
+
+func (t *progressTracker) add(n int64) {
+	if t == nil || n <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.bytes += n
+	t.report(false)
+}
+
+func (t *progressTracker) partCompleted() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.parts++
+	t.report(false)
+}
+
+// finish reports the final progress once. The total becomes the transferred size when it was unknown.
+func (t *progressTracker) finish() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return
+	}
+
+	t.done = true
+	t.total = t.bytes
+	t.report(true)
+}
+
+// report calls the listener when forced or when the interval has passed. The caller must hold mu.
+func (t *progressTracker) report(force bool) {
+	now := time.Now()
+
+	if !force && now.Sub(t.last) < t.interval {
+		return
+	}
+
+	t.last = now
+
+	if t.total < 0 && t.totalFn != nil {
+		if total, ok := t.totalFn(); ok {
+			t.total = total
+		}
+	}
+
+	p := Progress{
+		Bytes:          t.bytes,
+		Total:          t.total,
+		PartsCompleted: t.parts,
+		Elapsed:        now.Sub(t.start),
+		Done:           t.done,
+	}
+
+	if seconds := p.Elapsed.Seconds(); seconds > 0 {
+		p.Throughput = float64(p.Bytes) / seconds
+	}
+
+	t.listener(p)
+}
+
+func (t *progressTracker) reader(r io.Reader) io.Reader {
+	if t == nil || r == nil {
+		return r
+	}
+
+	return &progressReader{Reader: r, tracker: t}
+}
+
+func (t *progressTracker) readCloser(r io.ReadCloser) io.ReadCloser {
+	if t == nil || r == nil {
+		return r
+	}
+
+	return &progressReadCloser{ReadCloser: r, tracker: t}
+}
+
+func (t *progressTracker) writerAt(w io.WriterAt) io.WriterAt {
+	if t == nil {
+		return w
+	}
+
+	return &progressWriterAt{WriterAt: w, tracker: t}
+}
+
+// uploaderOption counts the parts sent by the uploader.
+func (t *progressTracker) uploaderOption(u *s3manager.Uploader) {
+	opts := make([]request.Option, 0, len(u.RequestOptions)+1)
+	opts = append(opts, u.RequestOptions...)
+	u.RequestOptions = append(opts, t.requestOption(progressOpUploadPart, progressOpPutObject))
+}
+
+// downloaderOption counts the parts received by the downloader.
+func (t *progressTracker) downloaderOption(d *s3manager.Downloader) {
+	opts := make([]request.Option, 0, len(d.RequestOptions)+1)
+	opts = append(opts, d.RequestOptions...)
+	d.RequestOptions = append(opts, t.requestOption(progressOpGetObject))
+}
+
+func (t *progressTracker) requestOption(ops ...string) request.Option {
+	return func(r *request.Request) {
+		r.Handlers.Complete.PushBack(func(r *request.Request) {
+			if r.Error != nil || r.Operation == nil {
+				return
+			}
+
+			for _, op := range ops {
+				if r.Operation.Name == op {
+					t.partCompleted()
+					return
+				}
+			}
+		})
+	}
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.tracker.add(int64(n))
+
+	return n, err
+}
+
+// Read reports the final progress when the body has been read to the end.
+func (r *progressReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.tracker.add(int64(n))
+
+	if err == io.EOF {
+		r.tracker.finish()
+	}
+
+	return n, err
+}
+
+func (w *progressWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	n, err := w.WriterAt.WriteAt(p, off)
+	w.tracker.add(int64(n))
+
+	return n, err
+}
+
+// readerSize returns the number of bytes left in r, or -1 when r does not tell it.
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case io.Seeker:
+		cur, err := v.Seek(0, io.SeekCurrent)
+
+		if err != nil {
+			return -1
+		}
+
+		end, err := v.Seek(0, io.SeekEnd)
+
+		if err != nil {
+			return -1
+		}
+
+		if _, err = v.Seek(cur, io.SeekStart); err != nil {
+			return -1
+		}
+
+		return end - cur
+	}
+
+	return -1
+}
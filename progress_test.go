@@ -0,0 +1,185 @@
+package aws_manager
+
+import (
+	"bytes"
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+const (
+	progressTestPartSize = 5 * 1024 * 1024
+	progressTestSize     = 2*progressTestPartSize + 1024
+)
+
+type progressRecorder struct {
+	mu     sync.Mutex
+	events []Progress
+}
+
+func (r *progressRecorder) listener(p Progress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, p)
+}
+
+func (r *progressRecorder) last() Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.events[len(r.events)-1]
+}
+
+func (suite *AwsManagerTestSuite) assertProgressMonotonic(events []Progress) {
+	for i := 1; i < len(events); i++ {
+		assert.True(suite.T(), events[i].Bytes >= events[i-1].Bytes)
+		assert.True(suite.T(), events[i].PartsCompleted >= events[i-1].PartsCompleted)
+		assert.False(suite.T(), events[i-1].Done)
+	}
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Upload_Progress_Ok() {
+	manager := suite.newStandInManager(UploadPartSize(progressTestPartSize))
+	recorder := &progressRecorder{}
+
+	_, err := manager.Upload(context.TODO(), &UploadInput{
+		Body:             bytes.NewReader(make([]byte, progressTestSize)),
+		FileName:         fileName,
+		Progress:         recorder.listener,
+		ProgressInterval: time.Nanosecond,
+	})
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), len(recorder.events) > 1)
+	suite.assertProgressMonotonic(recorder.events)
+	assert.Equal(suite.T(), int64(progressTestSize), recorder.events[0].Total)
+
+	last := recorder.last()
+	assert.True(suite.T(), last.Done)
+	assert.Equal(suite.T(), int64(progressTestSize), last.Bytes)
+	assert.Equal(suite.T(), int64(progressTestSize), last.Total)
+	assert.Equal(suite.T(), 3, last.PartsCompleted)
+	assert.True(suite.T(), last.Throughput > 0)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Upload_Progress_MaxUploadParts_Ok() {
+	manager := suite.newStandInManager(UploadMaxParts(2))
+	body := bytes.Repeat([]byte("a"), 12*1024*1024)
+	recorder := &progressRecorder{}
+
+	_, err := manager.Upload(context.TODO(), &UploadInput{
+		Body:     bytes.NewReader(body),
+		FileName: fileName,
+		Progress: recorder.listener,
+	})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, recorder.last().PartsCompleted)
+
+	object, ok := suite.s3Server.Object(standInBucket, fileName)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), body, object.Body)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Upload_ProgressUnknownSize_Ok() {
+	manager := suite.newStandInManager()
+	recorder := &progressRecorder{}
+
+	_, err := manager.Upload(context.TODO(), &UploadInput{
+		Body:     ioutil.NopCloser(bytes.NewReader([]byte("content"))),
+		FileName: fileName,
+		Progress: recorder.listener,
+	})
+	assert.NoError(suite.T(), err)
+
+	last := recorder.last()
+	assert.True(suite.T(), last.Done)
+	assert.Equal(suite.T(), int64(7), last.Total)
+	assert.Equal(suite.T(), 1, last.PartsCompleted)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_DownloadTo_Progress_Ok() {
+	suite.s3Server.PutObject(standInBucket, fileName, &test.S3Object{Body: make([]byte, progressTestSize)})
+	manager := suite.newStandInManager(DownloadPartSize(progressTestPartSize))
+	recorder := &progressRecorder{}
+
+	_, err := manager.DownloadTo(context.TODO(), aws.NewWriteAtBuffer(nil), &DownloadInput{
+		FileName:         fileName,
+		Progress:         recorder.listener,
+		ProgressInterval: time.Nanosecond,
+	})
+	assert.NoError(suite.T(), err)
+	suite.assertProgressMonotonic(recorder.events)
+
+	for _, p := range recorder.events[1:] {
+		assert.Equal(suite.T(), int64(progressTestSize), p.Total)
+	}
+
+	last := recorder.last()
+	assert.True(suite.T(), last.Done)
+	assert.Equal(suite.T(), int64(progressTestSize), last.Bytes)
+	assert.Equal(suite.T(), 3, last.PartsCompleted)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Open_Progress_Ok() {
+	suite.s3Server.PutObject(standInBucket, fileName, &test.S3Object{Body: []byte("content")})
+	recorder := &progressRecorder{}
+
+	body, _, err := suite.newStandInManager().Open(context.TODO(), &DownloadInput{
+		FileName: fileName,
+		Progress: recorder.listener,
+	})
+	assert.NoError(suite.T(), err)
+
+	_, err = ioutil.ReadAll(body)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), body.Close())
+
+	last := recorder.last()
+	assert.True(suite.T(), last.Done)
+	assert.Equal(suite.T(), int64(7), last.Bytes)
+	assert.Equal(suite.T(), int64(7), last.Total)
+}
+
+func (suite *AwsManagerTestSuite) TestProgressTracker_RateLimited() {
+	recorder := &progressRecorder{}
+	tracker := newProgressTracker(recorder.listener, time.Hour, 30)
+
+	for i := 0; i < 3; i++ {
+		tracker.add(10)
+	}
+
+	assert.Empty(suite.T(), recorder.events)
+
+	tracker.finish()
+	tracker.finish()
+	assert.Len(suite.T(), recorder.events, 1)
+	assert.Equal(suite.T(), int64(30), recorder.last().Bytes)
+	assert.Equal(suite.T(), int64(30), recorder.last().Total)
+	assert.True(suite.T(), recorder.last().Done)
+}
+
+func (suite *AwsManagerTestSuite) TestProgressChan_KeepsLatest() {
+	ch := make(chan Progress, 1)
+	listener := ProgressChan(ch)
+
+	listener(Progress{Bytes: 1})
+	listener(Progress{Bytes: 2})
+	listener(Progress{Bytes: 3, Done: true})
+
+	p := <-ch
+	assert.Equal(suite.T(), int64(3), p.Bytes)
+	assert.True(suite.T(), p.Done)
+
+	select {
+	case <-ch:
+		assert.Fail(suite.T(), "unexpected progress")
+	default:
+	}
+
+	unbuffered := ProgressChan(make(chan Progress))
+	unbuffered(Progress{Bytes: 1})
+}
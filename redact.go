@@ -0,0 +1,204 @@
+package aws_manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	redactedValue          = "[REDACTED]"
+	redactedURLPassword    = "xxxxx"
+	accessKeyIdVisibleTail = 4
+)
+
+// The plain types have the fields of the redacted types without their formatting methods.
+type (
+	plainOptions       Options
+	plainBucketConfig  BucketConfig
+	plainUploadInput   UploadInput
+	plainDownloadInput DownloadInput
+	plainCopyInput     CopyInput
+	plainStatInput     StatInput
+)
+
+// Format prints the options with the secrets redacted, for any verb and flags, e.g. %+v.
+func (opts Options) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, formatDirective(f, verb), plainOptions(opts.redacted()))
+}
+
+func (opts Options) String() string {
+	return fmt.Sprintf("%+v", opts)
+}
+
+// MarshalJSON encodes the options with the secrets redacted.
+func (opts Options) MarshalJSON() ([]byte, error) {
+	return json.Marshal(plainOptions(opts.redacted()))
+}
+
+// Format prints the bucket config with the secrets redacted, for any verb and flags, e.g. %+v.
+func (c BucketConfig) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, formatDirective(f, verb), plainBucketConfig(c.redacted()))
+}
+
+func (c BucketConfig) String() string {
+	return fmt.Sprintf("%+v", c)
+}
+
+// MarshalJSON encodes the bucket config with the secrets redacted.
+func (c BucketConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(plainBucketConfig(c.redacted()))
+}
+
+// Describe returns a one line summary of the options which is safe to log at startup.
+func (opts *Options) Describe() string {
+	credentials := opts.CredentialsSource
+
+	if credentials == "" {
+		credentials = "chain"
+	}
+
+	parts := []string{
+		"bucket=" + opts.Bucket,
+		"region=" + opts.Region,
+		"credentials=" + credentials,
+	}
+
+	if opts.AccessKeyId != "" {
+		parts = append(parts, "access_key_id="+maskAccessKeyId(opts.AccessKeyId))
+	}
+
+	if opts.Profile != "" {
+		parts = append(parts, "profile="+opts.Profile)
+	}
+
+	if opts.AssumeRoleARN != "" {
+		parts = append(parts, "assume_role="+opts.AssumeRoleARN)
+	}
+
+	if opts.Endpoint != "" {
+		parts = append(parts, "endpoint="+redactURL(opts.Endpoint))
+	}
+
+	if opts.ProxyURL != "" {
+		parts = append(parts, "proxy="+redactURL(opts.ProxyURL))
+	}
+
+	if opts.ConfigFile != "" {
+		parts = append(parts, "config_file="+opts.ConfigFile)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// redacted returns a copy of the options without secrets. The access key id keeps its tail
+// to tell the keys apart, URLs keep everything but the password.
+func (opts Options) redacted() Options {
+	opts.AccessKeyId = maskAccessKeyId(opts.AccessKeyId)
+	opts.SecretAccessKey = redact(opts.SecretAccessKey)
+	opts.Token = redact(opts.Token)
+	opts.AssumeRoleExternalID = redact(opts.AssumeRoleExternalID)
+	opts.Endpoint = redactURL(opts.Endpoint)
+	opts.ProxyURL = redactURL(opts.ProxyURL)
+	opts.STSEndpoint = redactURL(opts.STSEndpoint)
+
+	return opts
+}
+
+// redacted returns a copy of the bucket config without secrets, in the same way as Options.redacted.
+func (c BucketConfig) redacted() BucketConfig {
+	c.AccessKeyId = maskAccessKeyId(c.AccessKeyId)
+	c.SecretAccessKey = redact(c.SecretAccessKey)
+	c.Token = redact(c.Token)
+	c.Endpoint = redactURL(c.Endpoint)
+
+	return c
+}
+
+// Format prints the input with the customer provided encryption key redacted.
+func (in UploadInput) Format(f fmt.State, verb rune) {
+	in.SSECustomerKey = redact(in.SSECustomerKey)
+	fmt.Fprintf(f, formatDirective(f, verb), plainUploadInput(in))
+}
+
+// Format prints the input with the customer provided encryption key redacted.
+func (in DownloadInput) Format(f fmt.State, verb rune) {
+	in.SSECustomerKey = redact(in.SSECustomerKey)
+	fmt.Fprintf(f, formatDirective(f, verb), plainDownloadInput(in))
+}
+
+// Format prints the input with the customer provided encryption keys redacted.
+func (in CopyInput) Format(f fmt.State, verb rune) {
+	in.SSECustomerKey = redact(in.SSECustomerKey)
+	in.CopySourceSSECustomerKey = redact(in.CopySourceSSECustomerKey)
+	fmt.Fprintf(f, formatDirective(f, verb), plainCopyInput(in))
+}
+
+// Format prints the input with the customer provided encryption key redacted.
+func (in StatInput) Format(f fmt.State, verb rune) {
+	in.SSECustomerKey = redact(in.SSECustomerKey)
+	fmt.Fprintf(f, formatDirective(f, verb), plainStatInput(in))
+}
+
+// formatDirective rebuilds the directive a Format method was called for.
+func formatDirective(f fmt.State, verb rune) string {
+	directive := "%"
+
+	for _, flag := range "+-# 0" {
+		if f.Flag(int(flag)) {
+			directive += string(flag)
+		}
+	}
+
+	if width, ok := f.Width(); ok {
+		directive += strconv.Itoa(width)
+	}
+
+	if precision, ok := f.Precision(); ok {
+		directive += "." + strconv.Itoa(precision)
+	}
+
+	return directive + string(verb)
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+
+	return redactedValue
+}
+
+func maskAccessKeyId(id string) string {
+	if len(id) <= accessKeyIdVisibleTail {
+		return redact(id)
+	}
+
+	return strings.Repeat("*", len(id)-accessKeyIdVisibleTail) + id[len(id)-accessKeyIdVisibleTail:]
+}
+
+// redactURL replaces the password of the URL. URLs which can't be parsed are redacted completely
+// as their user info can't be told apart.
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+
+	u, err := url.Parse(raw)
+
+	if err != nil {
+		return redactedValue
+	}
+
+	if u.User == nil {
+		return raw
+	}
+
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redactedURLPassword)
+	}
+
+	return u.String()
+}
@@ -0,0 +1,311 @@
+package aws_manager
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/kelseyhightower/envconfig"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultRegion = "eu-west-1"
+
+	registryBucketsEnv      = "AWS_BUCKETS"
+	registryBucketEnvPrefix = "AWS_BUCKET"
+)
+
+var (
+	ErrEmptyRegistry = errors.New("registry has no buckets")
+)
+
+// Registry holds a manager per bucket alias. Managers of buckets in the same region share the session.
+type Registry struct {
+	managers map[string]AwsManagerInterface
+	sessions map[string]*session.Session
+}
+
+// BucketConfig describes the bucket of an alias. Empty fields fall back to the options of the registry.
+type BucketConfig struct {
+	Bucket            string `yaml:"bucket" json:"bucket,omitempty" envconfig:"NAME"`
+	Region            string `yaml:"region" json:"region,omitempty" envconfig:"REGION"`
+	AccessKeyId       string `yaml:"access_key_id" json:"access_key_id,omitempty" envconfig:"ACCESS_KEY_ID"`
+	SecretAccessKey   string `yaml:"secret_access_key" json:"secret_access_key,omitempty" envconfig:"SECRET_ACCESS_KEY"`
+	Token             string `yaml:"token" json:"token,omitempty" envconfig:"TOKEN"`
+	CredentialsSource string `yaml:"credentials_source" json:"credentials_source,omitempty" envconfig:"CREDENTIALS_SOURCE"`
+	Profile           string `yaml:"profile" json:"profile,omitempty" envconfig:"PROFILE"`
+	AssumeRoleARN     string `yaml:"assume_role_arn" json:"assume_role_arn,omitempty" envconfig:"ASSUME_ROLE_ARN"`
+	Endpoint          string `yaml:"endpoint" json:"endpoint,omitempty" envconfig:"ENDPOINT"`
+	S3ForcePathStyle  bool   `yaml:"s3_force_path_style" json:"s3_force_path_style,omitempty" envconfig:"S3_FORCE_PATH_STYLE"`
+	UploadDefaults    `yaml:"defaults" json:"defaults"`
+}
+
+// UploadDefaults are the fields of UploadInput applied to uploads which leave them empty.
+// Metadata keys are added unless the upload sets them.
+type UploadDefaults struct {
+	ACL                  string            `yaml:"acl" json:"acl,omitempty" envconfig:"ACL"`
+	CacheControl         string            `yaml:"cache_control" json:"cache_control,omitempty" envconfig:"CACHE_CONTROL"`
+	Metadata             map[string]string `yaml:"metadata" json:"metadata,omitempty" envconfig:"METADATA"`
+	SSEKMSKeyId          string            `yaml:"sse_kms_key_id" json:"sse_kms_key_id,omitempty" envconfig:"SSE_KMS_KEY_ID"`
+	ServerSideEncryption string            `yaml:"server_side_encryption" json:"server_side_encryption,omitempty" envconfig:"SERVER_SIDE_ENCRYPTION"`
+	StorageClass         string            `yaml:"storage_class" json:"storage_class,omitempty" envconfig:"STORAGE_CLASS"`
+	Tagging              string            `yaml:"tagging" json:"tagging,omitempty" envconfig:"TAGGING"`
+}
+
+type registryFile struct {
+	Buckets map[string]*BucketConfig `yaml:"buckets"`
+}
+
+// NewRegistry creates the managers of the buckets. The options are shared by all buckets,
+// e.g. credentials, retries and transport settings, and are not read from the environment.
+func NewRegistry(buckets map[string]*BucketConfig, options ...Option) (*Registry, error) {
+	if len(buckets) == 0 {
+		return nil, ErrEmptyRegistry
+	}
+
+	base := &Options{}
+
+	for _, opt := range options {
+		opt(base)
+	}
+
+	r := &Registry{
+		managers: make(map[string]AwsManagerInterface, len(buckets)),
+		sessions: make(map[string]*session.Session),
+	}
+
+	for alias, cfg := range buckets {
+		if cfg == nil || cfg.Bucket == "" {
+			return nil, fmt.Errorf("bucket of alias %q is not set", alias)
+		}
+
+		conn := cfg.options(base)
+
+		if err := conn.Validate(); err != nil {
+			return nil, fmt.Errorf("bucket alias %q: %v", alias, err)
+		}
+
+		sess, err := r.session(conn)
+
+		if err != nil {
+			return nil, err
+		}
+
+		manager, err := newManager(sess, conn)
+
+		if err != nil {
+			return nil, fmt.Errorf("bucket alias %q: %v", alias, err)
+		}
+
+		r.managers[alias] = manager
+	}
+
+	return r, nil
+}
+
+// NewRegistryFromEnv reads the comma separated aliases from AWS_BUCKETS and the config of each alias
+// from the variables prefixed with AWS_BUCKET_<ALIAS>_, e.g. AWS_BUCKET_INVOICES_NAME and AWS_BUCKET_INVOICES_REGION.
+func NewRegistryFromEnv(options ...Option) (*Registry, error) {
+	buckets := make(map[string]*BucketConfig)
+
+	for _, alias := range strings.Split(os.Getenv(registryBucketsEnv), ",") {
+		alias = strings.TrimSpace(alias)
+
+		if alias == "" {
+			continue
+		}
+
+		cfg := &BucketConfig{}
+
+		if err := envconfig.Process(registryEnvPrefix(alias), cfg); err != nil {
+			return nil, err
+		}
+
+		buckets[alias] = cfg
+	}
+
+	return NewRegistry(buckets, options...)
+}
+
+// NewRegistryFromFile reads the buckets from a YAML or JSON file in the form {"buckets": {"<alias>": {...}}}.
+// Unknown keys are reported as errors, like in LoadOptions.
+func NewRegistryFromFile(path string, options ...Option) (*Registry, error) {
+	b, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+
+	file := &registryFile{}
+
+	if err = yaml.UnmarshalStrict(b, file); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+
+	return NewRegistry(file.Buckets, options...)
+}
+
+// Bucket returns the manager of the alias and panics when the alias is not registered,
+// use Lookup for aliases which are not known in advance.
+func (r *Registry) Bucket(alias string) AwsManagerInterface {
+	manager, ok := r.managers[alias]
+
+	if !ok {
+		panic(fmt.Sprintf("aws_manager: unknown bucket alias %q", alias))
+	}
+
+	return manager
+}
+
+func (r *Registry) Lookup(alias string) (AwsManagerInterface, bool) {
+	manager, ok := r.managers[alias]
+	return manager, ok
+}
+
+// Aliases returns the sorted aliases of the registry.
+func (r *Registry) Aliases() []string {
+	aliases := make([]string, 0, len(r.managers))
+
+	for alias := range r.managers {
+		aliases = append(aliases, alias)
+	}
+
+	sort.Strings(aliases)
+
+	return aliases
+}
+
+func (r *Registry) session(conn *Options) (*session.Session, error) {
+	if sess, ok := r.sessions[conn.Region]; ok {
+		return sess, nil
+	}
+
+	sess, err := newSession(conn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.sessions[conn.Region] = sess
+
+	return sess, nil
+}
+
+// options returns the registry options overridden by the non empty fields of the bucket config.
+func (c *BucketConfig) options(base *Options) *Options {
+	conn := *base
+	conn.Bucket = c.Bucket
+
+	if c.Region != "" {
+		conn.Region = c.Region
+	}
+
+	if conn.Region == "" {
+		conn.Region = defaultRegion
+	}
+
+	if c.AccessKeyId != "" {
+		conn.AccessKeyId = c.AccessKeyId
+		conn.SecretAccessKey = c.SecretAccessKey
+		conn.Token = c.Token
+	}
+
+	if c.CredentialsSource != "" {
+		conn.CredentialsSource = c.CredentialsSource
+	}
+
+	if c.Profile != "" {
+		conn.Profile = c.Profile
+	}
+
+	if c.AssumeRoleARN != "" {
+		conn.AssumeRoleARN = c.AssumeRoleARN
+	}
+
+	if c.Endpoint != "" {
+		conn.Endpoint = c.Endpoint
+	}
+
+	if c.S3ForcePathStyle {
+		conn.S3ForcePathStyle = aws.Bool(c.S3ForcePathStyle)
+	}
+
+	if !c.UploadDefaults.isEmpty() {
+		defaults := c.UploadDefaults
+		conn.Defaults = &defaults
+	}
+
+	return &conn
+}
+
+func (d *UploadDefaults) isEmpty() bool {
+	return d.ACL == "" && d.CacheControl == "" && len(d.Metadata) == 0 && d.SSEKMSKeyId == "" &&
+		d.ServerSideEncryption == "" && d.StorageClass == "" && d.Tagging == ""
+}
+
+// apply sets the empty fields of in. The metadata of in is replaced by a merged copy.
+func (d *UploadDefaults) apply(in *UploadInput) {
+	if d == nil {
+		return
+	}
+
+	if in.ACL == "" {
+		in.ACL = d.ACL
+	}
+
+	if in.CacheControl == "" {
+		in.CacheControl = d.CacheControl
+	}
+
+	if in.SSEKMSKeyId == "" {
+		in.SSEKMSKeyId = d.SSEKMSKeyId
+	}
+
+	if in.ServerSideEncryption == "" {
+		in.ServerSideEncryption = d.ServerSideEncryption
+	}
+
+	if in.StorageClass == "" {
+		in.StorageClass = d.StorageClass
+	}
+
+	if in.Tagging == "" {
+		in.Tagging = d.Tagging
+	}
+
+	if len(d.Metadata) == 0 {
+		return
+	}
+
+	metadata := make(map[string]string, len(d.Metadata)+len(in.Metadata))
+
+	for k, v := range d.Metadata {
+		metadata[k] = v
+	}
+
+	for k, v := range in.Metadata {
+		metadata[k] = v
+	}
+
+	in.Metadata = metadata
+}
+
+func registryEnvPrefix(alias string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+
+		return '_'
+	}, alias)
+
+	return registryBucketEnvPrefix + "_" + name
+}
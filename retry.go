@@ -0,0 +1,73 @@
+package aws_manager
+
+import (
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries    = 3
+	defaultRetryMinDelay = 50 * time.Millisecond
+	defaultRetryMaxDelay = 5 * time.Second
+
+	retryMaxShift = 30
+)
+
+// backoffRetryer retries the requests the SDK considers retryable, waiting an exponentially
+// growing delay between minDelay and maxDelay of which the second half is randomized.
+type backoffRetryer struct {
+	client.DefaultRetryer
+	minDelay time.Duration
+	maxDelay time.Duration
+
+	mu   *sync.Mutex
+	rand *rand.Rand
+}
+
+// NewRetryer returns a retryer with exponential backoff and jitter. Zero delays are replaced by defaults.
+func NewRetryer(maxRetries int, minDelay, maxDelay time.Duration) request.Retryer {
+	if minDelay <= 0 {
+		minDelay = defaultRetryMinDelay
+	}
+
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	if maxDelay < minDelay {
+		maxDelay = minDelay
+	}
+
+	return &backoffRetryer{
+		DefaultRetryer: client.DefaultRetryer{NumMaxRetries: maxRetries},
+		minDelay:       minDelay,
+		maxDelay:       maxDelay,
+		mu:             &sync.Mutex{},
+		rand:           rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (r *backoffRetryer) RetryRules(req *request.Request) time.Duration {
+	shift := req.RetryCount
+
+	if shift > retryMaxShift {
+		shift = retryMaxShift
+	}
+
+	delay := r.minDelay << uint(shift)
+
+	if delay > r.maxDelay || delay < r.minDelay {
+		delay = r.maxDelay
+	}
+
+	half := int64(delay / 2)
+
+	r.mu.Lock()
+	jitter := r.rand.Int63n(half + 1)
+	r.mu.Unlock()
+
+	return time.Duration(half + jitter)
+}
@@ -0,0 +1,102 @@
+package aws_manager
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewRetryer_RetryRules_ExponentialWithJitter(t *testing.T) {
+	retryer := NewRetryer(5, 10*time.Millisecond, time.Second)
+	assert.Equal(t, 5, retryer.MaxRetries())
+
+	for retryCount := 0; retryCount < 70; retryCount++ {
+		ceiling := 10 * time.Millisecond << uint(retryCount)
+
+		if retryCount > 6 {
+			ceiling = time.Second
+		}
+
+		delay := retryer.RetryRules(&request.Request{RetryCount: retryCount})
+		assert.True(t, delay >= ceiling/2 && delay <= ceiling, "retry %d: %s", retryCount, delay)
+	}
+}
+
+func TestNewRetryer_Defaults(t *testing.T) {
+	r := NewRetryer(1, 0, 0).(*backoffRetryer)
+	assert.Equal(t, defaultRetryMinDelay, r.minDelay)
+	assert.Equal(t, defaultRetryMaxDelay, r.maxDelay)
+
+	r = NewRetryer(1, time.Second, time.Millisecond).(*backoffRetryer)
+	assert.Equal(t, time.Second, r.maxDelay)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_MaxRetries_RetriesServerErrors() {
+	server, requests := suite.newFlakyServer(2)
+	defer server.Close()
+
+	manager := suite.newStandInManager(Endpoint(server.URL), MaxRetries(2), RetryDelay(time.Millisecond, 2*time.Millisecond))
+	suite.s3Server.PutObject(standInBucket, fileName, &test.S3Object{Body: []byte("content")})
+
+	exists, err := manager.Exists(context.TODO(), fileName)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), exists)
+	assert.Equal(suite.T(), int32(3), atomic.LoadInt32(requests))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_MaxRetriesExceeded_Error() {
+	server, requests := suite.newFlakyServer(2)
+	defer server.Close()
+
+	manager := suite.newStandInManager(Endpoint(server.URL), MaxRetries(1), RetryDelay(time.Millisecond, 2*time.Millisecond))
+
+	_, err := manager.Exists(context.TODO(), fileName)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), int32(2), atomic.LoadInt32(requests))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_CustomRetryer_Ok() {
+	retryer := client.DefaultRetryer{NumMaxRetries: 7}
+	manager := suite.newStandInManager(Retryer(retryer))
+
+	c := manager.(*AwsManager).awsS3.(*s3.S3)
+	assert.Equal(suite.T(), retryer, c.Retryer)
+	assert.Equal(suite.T(), 7, c.MaxRetries())
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_MaxRetriesEnvVariable_Ok() {
+	defer suite.setEnv("AWS_MAX_RETRIES", "0")()
+
+	manager, err := New()
+	assert.NoError(suite.T(), err)
+
+	m := manager.(*AwsManager)
+	assert.Equal(suite.T(), 0, *m.cfg.MaxRetries)
+	assert.Equal(suite.T(), 0, m.awsS3.(*s3.S3).MaxRetries())
+	assert.IsType(suite.T(), &backoffRetryer{}, m.awsS3.(*s3.S3).Retryer)
+}
+
+// newFlakyServer returns a server which answers the first failures requests with an internal error
+// and passes the next ones to the S3 stand-in.
+func (suite *AwsManagerTestSuite) newFlakyServer(failures int32) (*httptest.Server, *int32) {
+	requests := new(int32)
+	handler := suite.s3Server.Config.Handler
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(requests, 1) <= failures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	}))
+
+	return server, requests
+}
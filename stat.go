@@ -0,0 +1,155 @@
+package aws_manager
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"time"
+)
+
+type StatInput struct {
+	Bucket               string
+	IfMatch              string
+	IfModifiedSince      time.Time
+	IfNoneMatch          string
+	IfUnmodifiedSince    time.Time
+	FileName             string
+	PartNumber           *int64
+	Range                string
+	RequestPayer         string
+	SSECustomerAlgorithm string
+	SSECustomerKey       string
+	SSECustomerKeyMD5    string
+	VersionId            string
+}
+
+type ObjectInfo struct {
+	Bucket               string
+	FileName             string
+	Size                 int64
+	ETag                 string
+	CacheControl         string
+	ContentDisposition   string
+	ContentEncoding      string
+	ContentLanguage      string
+	ContentType          string
+	DeleteMarker         bool
+	LastModified         time.Time
+	Metadata             map[string]string
+	PartsCount           int64
+	SSECustomerAlgorithm string
+	SSEKMSKeyId          string
+	ServerSideEncryption string
+	StorageClass         string
+	VersionId            string
+}
+
+// Stat returns metadata of the object without fetching its body.
+// The error of a missing object satisfies IsNotFound.
+func (m *AwsManager) Stat(ctx context.Context, in *StatInput) (*ObjectInfo, error) {
+	if in.Bucket == "" {
+		in.Bucket = m.cfg.Bucket
+	}
+
+	s3In := in.toAwsHeadObjectInput()
+	s3Out, err := m.awsS3.HeadObjectWithContext(ctx, s3In)
+
+	if err != nil {
+		return nil, wrapError(err, "Stat", in.Bucket, in.FileName, in.SSECustomerKey != "")
+	}
+
+	return newObjectInfoFromHead(in.Bucket, in.FileName, s3Out), nil
+}
+
+func (m *AwsManager) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := m.Stat(ctx, &StatInput{FileName: key})
+
+	if IsNotFound(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (m *StatInput) toAwsHeadObjectInput() *s3.HeadObjectInput {
+	out := &s3.HeadObjectInput{
+		PartNumber: m.PartNumber,
+	}
+
+	if m.Bucket != "" {
+		out.Bucket = aws.String(m.Bucket)
+	}
+
+	if m.IfMatch != "" {
+		out.IfMatch = aws.String(m.IfMatch)
+	}
+
+	if !m.IfModifiedSince.IsZero() {
+		out.IfModifiedSince = aws.Time(m.IfModifiedSince)
+	}
+
+	if m.IfNoneMatch != "" {
+		out.IfNoneMatch = aws.String(m.IfNoneMatch)
+	}
+
+	if !m.IfUnmodifiedSince.IsZero() {
+		out.IfUnmodifiedSince = aws.Time(m.IfUnmodifiedSince)
+	}
+
+	if m.FileName != "" {
+		out.Key = aws.String(m.FileName)
+	}
+
+	if m.Range != "" {
+		out.Range = aws.String(m.Range)
+	}
+
+	if m.RequestPayer != "" {
+		out.RequestPayer = aws.String(m.RequestPayer)
+	}
+
+	if m.SSECustomerAlgorithm != "" {
+		out.SSECustomerAlgorithm = aws.String(m.SSECustomerAlgorithm)
+	}
+
+	if m.SSECustomerKey != "" {
+		out.SSECustomerKey = aws.String(m.SSECustomerKey)
+	}
+
+	if m.SSECustomerKeyMD5 != "" {
+		out.SSECustomerKeyMD5 = aws.String(m.SSECustomerKeyMD5)
+	}
+
+	if m.VersionId != "" {
+		out.VersionId = aws.String(m.VersionId)
+	}
+
+	return out
+}
+
+func newObjectInfoFromHead(bucket, key string, in *s3.HeadObjectOutput) *ObjectInfo {
+	return &ObjectInfo{
+		Bucket:               bucket,
+		FileName:             key,
+		Size:                 aws.Int64Value(in.ContentLength),
+		ETag:                 aws.StringValue(in.ETag),
+		CacheControl:         aws.StringValue(in.CacheControl),
+		ContentDisposition:   aws.StringValue(in.ContentDisposition),
+		ContentEncoding:      aws.StringValue(in.ContentEncoding),
+		ContentLanguage:      aws.StringValue(in.ContentLanguage),
+		ContentType:          aws.StringValue(in.ContentType),
+		DeleteMarker:         aws.BoolValue(in.DeleteMarker),
+		LastModified:         aws.TimeValue(in.LastModified),
+		Metadata:             aws.StringValueMap(in.Metadata),
+		PartsCount:           aws.Int64Value(in.PartsCount),
+		SSECustomerAlgorithm: aws.StringValue(in.SSECustomerAlgorithm),
+		SSEKMSKeyId:          aws.StringValue(in.SSEKMSKeyId),
+		ServerSideEncryption: aws.StringValue(in.ServerSideEncryption),
+		StorageClass:         aws.StringValue(in.StorageClass),
+		VersionId:            aws.StringValue(in.VersionId),
+	}
+}
@@ -0,0 +1,127 @@
+package aws_manager
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"time"
+)
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Stat_Ok() {
+	lastModified := time.Now().UTC().Truncate(time.Second)
+	mockS3 := &test.S3API{}
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).
+		Return(
+			&s3.HeadObjectOutput{
+				ContentLength: aws.Int64(100),
+				ContentType:   aws.String("application/pdf"),
+				ETag:          aws.String(`"d41d8cd98f00b204e9800998ecf8427e"`),
+				LastModified:  aws.Time(lastModified),
+				Metadata:      map[string]*string{"Merchant": aws.String("1")},
+				VersionId:     aws.String("VersionId"),
+			},
+			nil,
+		)
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	in := &StatInput{
+		FileName:             fileName,
+		IfMatch:              "IfMatch",
+		IfModifiedSince:      time.Now(),
+		IfNoneMatch:          "IfNoneMatch",
+		IfUnmodifiedSince:    time.Now(),
+		PartNumber:           aws.Int64(1),
+		Range:                "Range",
+		RequestPayer:         "RequestPayer",
+		SSECustomerAlgorithm: "SSECustomerAlgorithm",
+		SSECustomerKey:       "SSECustomerKey",
+		SSECustomerKeyMD5:    "SSECustomerKeyMD5",
+		VersionId:            "VersionId",
+	}
+	info, err := suite.awsManager.Stat(context.TODO(), in)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.awsManager.(*AwsManager).cfg.Bucket, info.Bucket)
+	assert.Equal(suite.T(), fileName, info.FileName)
+	assert.Equal(suite.T(), int64(100), info.Size)
+	assert.Equal(suite.T(), "application/pdf", info.ContentType)
+	assert.Equal(suite.T(), `"d41d8cd98f00b204e9800998ecf8427e"`, info.ETag)
+	assert.Equal(suite.T(), lastModified, info.LastModified)
+	assert.Equal(suite.T(), map[string]string{"Merchant": "1"}, info.Metadata)
+	assert.Equal(suite.T(), "VersionId", info.VersionId)
+
+	s3In := mockS3.Calls[0].Arguments.Get(1).(*s3.HeadObjectInput)
+	assert.Equal(suite.T(), fileName, aws.StringValue(s3In.Key))
+	assert.Equal(suite.T(), "SSECustomerKey", aws.StringValue(s3In.SSECustomerKey))
+	assert.Equal(suite.T(), "IfMatch", aws.StringValue(s3In.IfMatch))
+	assert.Equal(suite.T(), int64(1), aws.Int64Value(s3In.PartNumber))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Stat_NotFound_Error() {
+	mockS3 := &test.S3API{}
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).
+		Return(nil, awserr.NewRequestFailure(awserr.New("NotFound", "Not Found", nil), http.StatusNotFound, "RequestId"))
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	info, err := suite.awsManager.Stat(context.TODO(), &StatInput{FileName: fileName})
+	assert.Nil(suite.T(), info)
+	assert.True(suite.T(), IsNotFound(err))
+	assert.False(suite.T(), IsRetryable(err))
+
+	e, ok := err.(*Error)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), "Stat", e.Op)
+	assert.Equal(suite.T(), suite.awsManager.(*AwsManager).cfg.Bucket, e.Bucket)
+	assert.Equal(suite.T(), fileName, e.Key)
+	assert.Equal(suite.T(), "RequestId", e.RequestId)
+	assert.Equal(suite.T(), ErrNotFound, e.Kind)
+	assert.Equal(suite.T(), http.StatusNotFound, e.StatusCode())
+	assert.Equal(suite.T(), "NotFound", e.Code())
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Stat_RequestError() {
+	mockS3 := &test.S3API{}
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).
+		Return(nil, awserr.NewRequestFailure(awserr.New("Forbidden", "Forbidden", nil), http.StatusForbidden, "RequestId"))
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	info, err := suite.awsManager.Stat(context.TODO(), &StatInput{FileName: fileName})
+	assert.Nil(suite.T(), info)
+	assert.Error(suite.T(), err)
+	assert.False(suite.T(), IsNotFound(err))
+	assert.True(suite.T(), IsAccessDenied(err))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Exists_Ok() {
+	mockS3 := &test.S3API{}
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.MatchedBy(func(in *s3.HeadObjectInput) bool {
+		return aws.StringValue(in.Key) == fileName
+	})).Return(&s3.HeadObjectOutput{}, nil)
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).
+		Return(nil, awserr.New(s3.ErrCodeNoSuchKey, "The specified key does not exist.", nil))
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	exists, err := suite.awsManager.Exists(context.TODO(), fileName)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), exists)
+
+	exists, err = suite.awsManager.Exists(context.TODO(), "not_exist_file_name.pdf")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), exists)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Exists_RequestError() {
+	mockS3 := &test.S3API{}
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).
+		Return(nil, errors.New("request failed"))
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	exists, err := suite.awsManager.Exists(context.TODO(), fileName)
+	assert.Error(suite.T(), err)
+	assert.False(suite.T(), exists)
+}
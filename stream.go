@@ -0,0 +1,101 @@
+package aws_manager
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"io"
+)
+
+// Open streams the object body without buffering it on disk. The caller must close the returned reader.
+// Reads of the body are throttled by the bandwidth limits of the manager and the input.
+func (m *AwsManager) Open(ctx context.Context, in *DownloadInput) (io.ReadCloser, *ObjectInfo, error) {
+	if in.Bucket == "" {
+		in.Bucket = m.cfg.Bucket
+	}
+
+	s3In := in.toAwsGetObjectInput()
+	s3Out, err := m.awsS3.GetObjectWithContext(ctx, s3In)
+
+	if err != nil {
+		return nil, nil, wrapError(err, "Open", in.Bucket, in.FileName, in.SSECustomerKey != "")
+	}
+
+	info := newObjectInfoFromGet(in.Bucket, in.FileName, s3Out)
+	progress := newProgressTracker(in.Progress, in.ProgressInterval, info.Size)
+
+	body := throttleReadCloser(ctx, s3Out.Body, m.transferLimiters(in.BandwidthLimit))
+
+	return progress.readCloser(body), info, nil
+}
+
+// DownloadTo writes the object into w using concurrent ranged requests, so w must allow writes at any offset,
+// for example aws.WriteAtBuffer or an opened file.
+func (m *AwsManager) DownloadTo(
+	ctx context.Context,
+	w io.WriterAt,
+	in *DownloadInput,
+	opts ...func(*s3manager.Downloader),
+) (*DownloadOutput, error) {
+	if in.Bucket == "" {
+		in.Bucket = m.cfg.Bucket
+	}
+
+	capture := newGetObjectCapture(in)
+	opts = append(opts, capture.option)
+	w = throttleWriterAt(ctx, w, m.transferLimiters(in.BandwidthLimit))
+	progress := newProgressTracker(in.Progress, in.ProgressInterval, -1)
+
+	if progress != nil {
+		progress.totalFn = capture.size
+		w = progress.writerAt(w)
+		opts = append(opts, progress.downloaderOption)
+	}
+
+	s3In := in.toAwsGetObjectInput()
+	n, err := m.awsDownloader.DownloadWithContext(ctx, w, s3In, m.downloaderOptions(opts))
+
+	if err != nil {
+		return nil, wrapError(err, "Download", in.Bucket, in.FileName, in.SSECustomerKey != "")
+	}
+
+	if size, ok := capture.size(); ok && size != n {
+		return nil, &Error{
+			Op:        "Download",
+			Bucket:    in.Bucket,
+			Key:       in.FileName,
+			RequestId: capture.downloadOutput(n).RequestId,
+			Kind:      ErrIncompleteDownload,
+			Err:       fmt.Errorf("received %d of %d bytes", n, size),
+		}
+	}
+
+	progress.finish()
+
+	return capture.downloadOutput(n), nil
+}
+
+func newObjectInfoFromGet(bucket, key string, in *s3.GetObjectOutput) *ObjectInfo {
+	return &ObjectInfo{
+		Bucket:               bucket,
+		FileName:             key,
+		Size:                 aws.Int64Value(in.ContentLength),
+		ETag:                 aws.StringValue(in.ETag),
+		CacheControl:         aws.StringValue(in.CacheControl),
+		ContentDisposition:   aws.StringValue(in.ContentDisposition),
+		ContentEncoding:      aws.StringValue(in.ContentEncoding),
+		ContentLanguage:      aws.StringValue(in.ContentLanguage),
+		ContentType:          aws.StringValue(in.ContentType),
+		DeleteMarker:         aws.BoolValue(in.DeleteMarker),
+		LastModified:         aws.TimeValue(in.LastModified),
+		Metadata:             aws.StringValueMap(in.Metadata),
+		PartsCount:           aws.Int64Value(in.PartsCount),
+		SSECustomerAlgorithm: aws.StringValue(in.SSECustomerAlgorithm),
+		SSEKMSKeyId:          aws.StringValue(in.SSEKMSKeyId),
+		ServerSideEncryption: aws.StringValue(in.ServerSideEncryption),
+		StorageClass:         aws.StringValue(in.StorageClass),
+		VersionId:            aws.StringValue(in.VersionId),
+	}
+}
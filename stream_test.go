@@ -0,0 +1,95 @@
+package aws_manager
+
+import (
+	"bytes"
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"io/ioutil"
+)
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Open_Ok() {
+	mockS3 := &test.S3API{}
+	mockS3.On("GetObjectWithContext", mock.Anything, mock.Anything).
+		Return(
+			&s3.GetObjectOutput{
+				Body:          ioutil.NopCloser(bytes.NewBufferString("content")),
+				ContentLength: aws.Int64(7),
+				ContentType:   aws.String("application/pdf"),
+				ETag:          aws.String(`"etag"`),
+				VersionId:     aws.String("VersionId"),
+			},
+			nil,
+		)
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	in := &DownloadInput{
+		FileName:                   fileName,
+		ResponseContentDisposition: "attachment",
+	}
+	body, info, err := suite.awsManager.Open(context.TODO(), in)
+	assert.NoError(suite.T(), err)
+	defer body.Close()
+
+	b, err := ioutil.ReadAll(body)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "content", string(b))
+	assert.Equal(suite.T(), int64(7), info.Size)
+	assert.Equal(suite.T(), fileName, info.FileName)
+	assert.Equal(suite.T(), "application/pdf", info.ContentType)
+	assert.Equal(suite.T(), `"etag"`, info.ETag)
+	assert.Equal(suite.T(), "VersionId", info.VersionId)
+
+	s3In := mockS3.Calls[0].Arguments.Get(1).(*s3.GetObjectInput)
+	assert.Equal(suite.T(), suite.awsManager.(*AwsManager).cfg.Bucket, aws.StringValue(s3In.Bucket))
+	assert.Equal(suite.T(), "attachment", aws.StringValue(s3In.ResponseContentDisposition))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Open_NotFound_Error() {
+	mockS3 := &test.S3API{}
+	mockS3.On("GetObjectWithContext", mock.Anything, mock.Anything).
+		Return(nil, awserr.New(s3.ErrCodeNoSuchKey, "The specified key does not exist.", nil))
+	suite.awsManager.(*AwsManager).awsS3 = mockS3
+
+	body, info, err := suite.awsManager.Open(context.TODO(), &DownloadInput{FileName: fileName})
+	assert.True(suite.T(), IsNotFound(err))
+	assert.Nil(suite.T(), body)
+	assert.Nil(suite.T(), info)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_DownloadTo_Ok() {
+	mockDownloader := &test.DownloaderAPI{}
+	mockDownloader.On("DownloadWithContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(
+			func(_ context.Context, w io.WriterAt, _ *s3.GetObjectInput, opts ...func(*s3manager.Downloader)) int64 {
+				d := &s3manager.Downloader{}
+
+				for _, opt := range opts {
+					opt(d)
+				}
+
+				n, _ := w.WriteAt([]byte("content"), d.PartSize)
+				return int64(n)
+			},
+			nil,
+		)
+	suite.awsManager.(*AwsManager).awsDownloader = mockDownloader
+
+	buf := aws.NewWriteAtBuffer(nil)
+	out, err := suite.awsManager.DownloadTo(context.TODO(), buf, &DownloadInput{FileName: fileName}, func(d *s3manager.Downloader) {
+		d.PartSize = 2
+	})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(7), out.Size)
+	assert.Equal(suite.T(), "\x00\x00content", string(buf.Bytes()))
+
+	s3In := mockDownloader.Calls[0].Arguments.Get(2).(*s3.GetObjectInput)
+	assert.Equal(suite.T(), suite.awsManager.(*AwsManager).cfg.Bucket, aws.StringValue(s3In.Bucket))
+	assert.Equal(suite.T(), fileName, aws.StringValue(s3In.Key))
+}
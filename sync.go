@@ -0,0 +1,389 @@
+package aws_manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SyncLocalToRemote = "local-to-remote"
+	SyncRemoteToLocal = "remote-to-local"
+
+	SyncOpUpload   = "upload"
+	SyncOpDownload = "download"
+	SyncOpDelete   = "delete"
+
+	defaultSyncConcurrency = 5
+)
+
+// SyncInput describes a sync between a local directory and a prefix.
+// Direction is SyncLocalToRemote or SyncRemoteToLocal. Include and Exclude filter the relative paths
+// of both sides as in UploadDirInput, so excluded files are neither transferred nor deleted.
+// With Delete the files of the destination missing from the source are removed.
+// With DryRun the planned actions are returned without being executed.
+// Comparator decides whether a destination file is up to date, SyncCompareETag with the part size
+// of the manager is used when it is nil. Downloads always preserve the last modified time.
+type SyncInput struct {
+	Bucket           string
+	Comparator       SyncComparator
+	Concurrency      int
+	Delete           bool
+	Direction        string
+	DownloadDefaults DownloadInput
+	DryRun           bool
+	Exclude          []string
+	FailFast         bool
+	Include          []string
+	UploadDefaults   UploadInput
+}
+
+// SyncFile is a local file or a remote object. LocalPath is set for local files and FileName
+// and ETag for objects, Path is relative to the directory or the prefix.
+type SyncFile struct {
+	Path      string
+	LocalPath string
+	FileName  string
+	Size      int64
+	ModTime   time.Time
+	ETag      string
+}
+
+// SyncComparator reports whether dst is up to date with src, in which case src is not transferred.
+type SyncComparator func(src, dst *SyncFile) (bool, error)
+
+type SyncOutput struct {
+	Actions   []*SyncAction
+	Unchanged int
+	DryRun    bool
+}
+
+// SyncAction is a transfer or a removal, planned or executed. Path is relative to the directory
+// and FileName is the key of the object, which is empty for local files to remove.
+// Actions which failed while planning, for example by the comparator, are not executed.
+type SyncAction struct {
+	Op       string
+	Path     string
+	FileName string
+	Size     int64
+	Err      error
+}
+
+// SyncCompareETag compares the sizes and then the content of the local file with the ETag of the object,
+// multipart ETags included.
+func SyncCompareETag(src, dst *SyncFile) (bool, error) {
+	return compareETag(src, dst, 0)
+}
+
+// SyncCompareSizeAndModTime takes dst for up to date when the sizes are equal and dst is not older than src.
+// It does not read the files, but trusts the clocks of the machine and of S3.
+func SyncCompareSizeAndModTime(src, dst *SyncFile) (bool, error) {
+	return src.Size == dst.Size && !dst.ModTime.Before(src.ModTime.Truncate(time.Second)), nil
+}
+
+// Sync makes the destination side match the source side, transferring only new and changed files.
+// Failed actions do not stop the others unless FailFast is set, then nothing is executed after
+// the first failure, deletions included. The output lists the transfers sorted by path followed by the deletions.
+func (m *AwsManager) Sync(ctx context.Context, localDir, keyPrefix string, in *SyncInput) (*SyncOutput, error) {
+	if in.Direction != SyncLocalToRemote && in.Direction != SyncRemoteToLocal {
+		return nil, fmt.Errorf("unknown sync direction %q", in.Direction)
+	}
+
+	if err := validatePatterns(in.Include, in.Exclude); err != nil {
+		return nil, err
+	}
+
+	if in.Bucket == "" {
+		in.Bucket = m.cfg.Bucket
+	}
+
+	if keyPrefix != "" && !strings.HasSuffix(keyPrefix, "/") {
+		keyPrefix += "/"
+	}
+
+	local, err := walkDir(localDir, in.Include, in.Exclude)
+
+	if err != nil && !(os.IsNotExist(err) && in.Direction == SyncRemoteToLocal) {
+		return nil, err
+	}
+
+	remote, err := m.syncRemoteFiles(ctx, keyPrefix, in)
+
+	if err != nil {
+		return nil, err
+	}
+
+	src, dst := local, remote
+
+	if in.Direction == SyncRemoteToLocal {
+		src, dst = remote, local
+	}
+
+	out := m.syncPlan(localDir, keyPrefix, in, src, dst)
+
+	if in.DryRun {
+		return out, nil
+	}
+
+	return out, m.syncExecute(ctx, localDir, in, out)
+}
+
+// Failed returns the actions which did not succeed.
+func (m *SyncOutput) Failed() []*SyncAction {
+	var failed []*SyncAction
+
+	for _, v := range m.Actions {
+		if v.Err != nil {
+			failed = append(failed, v)
+		}
+	}
+
+	return failed
+}
+
+func (m *AwsManager) syncRemoteFiles(ctx context.Context, keyPrefix string, in *SyncInput) ([]*SyncFile, error) {
+	var files []*SyncFile
+	it := m.List(ctx, &ListInput{Bucket: in.Bucket, Prefix: keyPrefix})
+
+	for it.Next() {
+		object := it.Object()
+		rel := strings.TrimPrefix(object.FileName, keyPrefix)
+
+		if strings.HasSuffix(rel, "/") || matchAny(in.Exclude, rel) {
+			continue
+		}
+
+		if len(in.Include) > 0 && !matchAny(in.Include, rel) {
+			continue
+		}
+
+		files = append(files, &SyncFile{
+			Path:     rel,
+			FileName: object.FileName,
+			Size:     object.Size,
+			ModTime:  object.LastModified,
+			ETag:     object.ETag,
+		})
+	}
+
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+func (m *AwsManager) syncPlan(localDir, keyPrefix string, in *SyncInput, src, dst []*SyncFile) *SyncOutput {
+	comparator := in.Comparator
+
+	if comparator == nil {
+		comparator = func(src, dst *SyncFile) (bool, error) {
+			return compareETag(src, dst, m.cfg.UploadPartSize)
+		}
+	}
+
+	op := SyncOpUpload
+
+	if in.Direction == SyncRemoteToLocal {
+		op = SyncOpDownload
+	}
+
+	existing := make(map[string]*SyncFile, len(dst))
+
+	for _, v := range dst {
+		existing[v.Path] = v
+	}
+
+	out := &SyncOutput{DryRun: in.DryRun}
+
+	for _, v := range src {
+		action := &SyncAction{Op: op, Path: v.Path, FileName: uploadDirKey(keyPrefix, v.Path), Size: v.Size}
+
+		if op == SyncOpDownload {
+			action.FileName = v.FileName
+
+			if _, err := localPath(localDir, v.Path); err != nil {
+				action.Err = unsafeKeyError("Sync", in.Bucket, v.FileName, err)
+				out.Actions = append(out.Actions, action)
+				continue
+			}
+		}
+
+		if d, ok := existing[v.Path]; ok {
+			delete(existing, v.Path)
+			upToDate, err := comparator(v, d)
+
+			if upToDate && err == nil {
+				out.Unchanged++
+				continue
+			}
+
+			action.Err = err
+		}
+
+		out.Actions = append(out.Actions, action)
+	}
+
+	if !in.Delete {
+		return out
+	}
+
+	var deletes []*SyncAction
+
+	for _, v := range existing {
+		deletes = append(deletes, &SyncAction{Op: SyncOpDelete, Path: v.Path, FileName: v.FileName, Size: v.Size})
+	}
+
+	sort.Slice(deletes, func(i, j int) bool {
+		return deletes[i].Path < deletes[j].Path
+	})
+
+	out.Actions = append(out.Actions, deletes...)
+
+	return out
+}
+
+func (m *AwsManager) syncExecute(ctx context.Context, localDir string, in *SyncInput, out *SyncOutput) error {
+	var transfers, deletes []*SyncAction
+
+	for _, v := range out.Actions {
+		switch {
+		case v.Err != nil && in.FailFast:
+			return v.Err
+		case v.Err != nil:
+		case v.Op == SyncOpDelete:
+			deletes = append(deletes, v)
+		default:
+			transfers = append(transfers, v)
+		}
+	}
+
+	concurrency := in.Concurrency
+
+	if concurrency <= 0 {
+		concurrency = defaultSyncConcurrency
+	}
+
+	err := runPool(ctx, concurrency, len(transfers), in.FailFast, func(ctx context.Context, i int) error {
+		return m.syncTransfer(ctx, localDir, in, transfers[i])
+	})
+
+	if err != nil || len(deletes) == 0 {
+		return err
+	}
+
+	if in.Direction == SyncRemoteToLocal {
+		for _, v := range deletes {
+			file, err := localPath(localDir, v.Path)
+
+			if err != nil {
+				err = unsafeKeyError("Sync", in.Bucket, v.FileName, err)
+			} else {
+				err = os.Remove(file)
+			}
+
+			if v.Err = err; err != nil && in.FailFast {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return m.syncDeleteRemote(ctx, in, deletes)
+}
+
+func (m *AwsManager) syncTransfer(ctx context.Context, localDir string, in *SyncInput, action *SyncAction) error {
+	if action.Err = ctx.Err(); action.Err != nil {
+		return action.Err
+	}
+
+	file, err := localPath(localDir, action.Path)
+
+	if err != nil {
+		action.Err = unsafeKeyError("Sync", in.Bucket, action.FileName, err)
+		return action.Err
+	}
+
+	if action.Op == SyncOpUpload {
+		fileIn := in.UploadDefaults
+		fileIn.Body = nil
+		fileIn.Bucket = in.Bucket
+		fileIn.Path = file
+		fileIn.FileName = action.FileName
+		_, action.Err = m.Upload(ctx, &fileIn)
+
+		return action.Err
+	}
+
+	if action.Err = os.MkdirAll(filepath.Dir(file), defaultDownloadDirMode); action.Err != nil {
+		return action.Err
+	}
+
+	fileIn := in.DownloadDefaults
+	fileIn.Bucket = in.Bucket
+	fileIn.FileName = action.FileName
+	fileIn.PreserveLastModified = true
+	_, action.Err = m.Download(ctx, file, &fileIn)
+
+	return action.Err
+}
+
+func (m *AwsManager) syncDeleteRemote(ctx context.Context, in *SyncInput, deletes []*SyncAction) error {
+	deleteIn := &DeleteManyInput{Bucket: in.Bucket}
+	actions := make(map[string]*SyncAction, len(deletes))
+
+	for _, v := range deletes {
+		deleteIn.Objects = append(deleteIn.Objects, &DeleteObject{FileName: v.FileName})
+		actions[v.FileName] = v
+	}
+
+	deleteOut, err := m.DeleteMany(ctx, deleteIn)
+	done := make(map[string]bool, len(deletes))
+
+	if deleteOut != nil {
+		for _, v := range deleteOut.Errors {
+			if action, ok := actions[v.FileName]; ok {
+				action.Err = v
+			}
+		}
+
+		for _, v := range deleteOut.Deleted {
+			done[v.FileName] = true
+		}
+	}
+
+	if err != nil {
+		for _, v := range deletes {
+			if !done[v.FileName] && v.Err == nil {
+				v.Err = err
+			}
+		}
+
+		return err
+	}
+
+	if errs := deleteOut.Errors; len(errs) > 0 && in.FailFast {
+		return errs[0]
+	}
+
+	return nil
+}
+
+func compareETag(src, dst *SyncFile, partSize int64) (bool, error) {
+	if src.Size != dst.Size {
+		return false, nil
+	}
+
+	local, remote := src, dst
+
+	if local.LocalPath == "" {
+		local, remote = dst, src
+	}
+
+	return fileMatchesETag(local.LocalPath, local.Size, remote.ETag, partSize)
+}
@@ -0,0 +1,213 @@
+package aws_manager
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// failingDeleteS3 passes the requests to the stand-in but fails the batch deletes.
+type failingDeleteS3 struct {
+	s3iface.S3API
+}
+
+func (s *failingDeleteS3) DeleteObjectsWithContext(
+	aws.Context,
+	*s3.DeleteObjectsInput,
+	...request.Option,
+) (*s3.DeleteObjectsOutput, error) {
+	return nil, awserr.NewRequestFailure(awserr.New("InternalError", "We encountered an internal error.", nil), http.StatusInternalServerError, "RequestId")
+}
+
+func (suite *AwsManagerTestSuite) syncOps(out *SyncOutput) []string {
+	var ops []string
+
+	for _, v := range out.Actions {
+		ops = append(ops, v.Op+" "+v.Path)
+	}
+
+	return ops
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Sync_LocalToRemote_Ok() {
+	dir := suite.newUploadDir("a.csv", "b.csv", "sub/c.csv", "tmp.log")
+	defer os.RemoveAll(dir)
+
+	suite.s3Server.PutObject(standInBucket, "reports/a.csv", &test.S3Object{Body: []byte("a.csv")})
+	suite.s3Server.PutObject(standInBucket, "reports/b.csv", &test.S3Object{Body: []byte("B.CSV")})
+	suite.s3Server.PutObject(standInBucket, "reports/old.csv", &test.S3Object{Body: []byte("old")})
+	suite.s3Server.PutObject(standInBucket, "reports/keep.log", &test.S3Object{Body: []byte("keep")})
+	suite.s3Server.PutObject(standInBucket, "other/old.csv", &test.S3Object{Body: []byte("other")})
+
+	manager := suite.newStandInManager()
+	in := &SyncInput{Direction: SyncLocalToRemote, Delete: true, DryRun: true, Exclude: []string{"*.log"}}
+
+	out, err := manager.Sync(context.TODO(), dir, "reports", in)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), out.DryRun)
+	assert.Equal(suite.T(), 1, out.Unchanged)
+	assert.Equal(suite.T(), []string{"upload b.csv", "upload sub/c.csv", "delete old.csv"}, suite.syncOps(out))
+	assert.Equal(suite.T(), "reports/sub/c.csv", out.Actions[1].FileName)
+	assert.Len(suite.T(), suite.s3Server.Keys(standInBucket), 5)
+
+	in.DryRun = false
+	out, err = manager.Sync(context.TODO(), dir, "reports", in)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), out.Failed())
+	assert.Equal(suite.T(), []string{
+		"other/old.csv",
+		"reports/a.csv",
+		"reports/b.csv",
+		"reports/keep.log",
+		"reports/sub/c.csv",
+	}, suite.s3Server.Keys(standInBucket))
+
+	object, _ := suite.s3Server.Object(standInBucket, "reports/b.csv")
+	assert.Equal(suite.T(), []byte("b.csv"), object.Body)
+
+	out, err = manager.Sync(context.TODO(), dir, "reports", in)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), out.Actions)
+	assert.Equal(suite.T(), 3, out.Unchanged)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Sync_RemoteToLocal_Ok() {
+	dir := suite.newUploadDir("a.csv", "b.csv", "extra.csv")
+	defer os.RemoveAll(dir)
+
+	suite.s3Server.PutObject(standInBucket, "reports/a.csv", &test.S3Object{Body: []byte("a.csv")})
+	suite.s3Server.PutObject(standInBucket, "reports/b.csv", &test.S3Object{Body: []byte("B.CSV")})
+	suite.s3Server.PutObject(standInBucket, "reports/sub/c.csv", &test.S3Object{Body: []byte("c.csv")})
+
+	manager := suite.newStandInManager()
+	in := &SyncInput{Direction: SyncRemoteToLocal, Delete: true}
+
+	out, err := manager.Sync(context.TODO(), dir, "reports/", in)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), out.Failed())
+	assert.Equal(suite.T(), 1, out.Unchanged)
+	assert.Equal(suite.T(), []string{"download b.csv", "download sub/c.csv", "delete extra.csv"}, suite.syncOps(out))
+	assert.Equal(suite.T(), "reports/b.csv", out.Actions[0].FileName)
+	assert.Empty(suite.T(), out.Actions[2].FileName)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "b.csv"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []byte("B.CSV"), content)
+
+	_, err = os.Stat(filepath.Join(dir, "extra.csv"))
+	assert.True(suite.T(), os.IsNotExist(err))
+
+	object, _ := suite.s3Server.Object(standInBucket, "reports/sub/c.csv")
+	fi, err := os.Stat(filepath.Join(dir, "sub", "c.csv"))
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), fi.ModTime().Equal(object.LastModified))
+
+	in.Comparator = SyncCompareSizeAndModTime
+	out, err = manager.Sync(context.TODO(), dir, "reports", in)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), out.Actions)
+	assert.Equal(suite.T(), 3, out.Unchanged)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Sync_RemoteToLocal_NewDir() {
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	suite.s3Server.PutObject(standInBucket, "reports/a.csv", &test.S3Object{Body: []byte("a.csv")})
+
+	out, err := suite.newStandInManager().Sync(context.TODO(), filepath.Join(dir, "restore"), "reports", &SyncInput{
+		Direction: SyncRemoteToLocal,
+	})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"download a.csv"}, suite.syncOps(out))
+
+	_, err = os.Stat(filepath.Join(dir, "restore", "a.csv"))
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Sync_Comparator() {
+	dir := suite.newUploadDir("a.csv", "b.csv")
+	defer os.RemoveAll(dir)
+
+	suite.s3Server.PutObject(standInBucket, "a.csv", &test.S3Object{Body: []byte("A")})
+	suite.s3Server.PutObject(standInBucket, "b.csv", &test.S3Object{Body: []byte("B")})
+
+	var compared []string
+	comparatorErr := errors.New("compare failed")
+
+	out, err := suite.newStandInManager().Sync(context.TODO(), dir, "", &SyncInput{
+		Direction: SyncLocalToRemote,
+		Comparator: func(src, dst *SyncFile) (bool, error) {
+			compared = append(compared, src.LocalPath+" "+dst.FileName)
+
+			if src.Path == "b.csv" {
+				return false, comparatorErr
+			}
+
+			return true, nil
+		},
+	})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{filepath.Join(dir, "a.csv") + " a.csv", filepath.Join(dir, "b.csv") + " b.csv"}, compared)
+	assert.Equal(suite.T(), 1, out.Unchanged)
+	assert.Len(suite.T(), out.Failed(), 1)
+	assert.Equal(suite.T(), comparatorErr, out.Actions[0].Err)
+
+	object, _ := suite.s3Server.Object(standInBucket, "b.csv")
+	assert.Equal(suite.T(), []byte("B"), object.Body)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Sync_Errors() {
+	_, err := suite.awsManager.Sync(context.TODO(), "./test", "", &SyncInput{Direction: "both"})
+	assert.EqualError(suite.T(), err, `unknown sync direction "both"`)
+
+	_, err = suite.awsManager.Sync(context.TODO(), "./not_exist", "", &SyncInput{Direction: SyncLocalToRemote})
+	assert.True(suite.T(), os.IsNotExist(err))
+
+	suite.s3Server.PutObject(standInBucket, "reports/../escape.csv", &test.S3Object{Body: []byte("escape")})
+	suite.s3Server.PutObject(standInBucket, "reports/a.csv", &test.S3Object{Body: []byte("a")})
+	dir := suite.newDownloadDir()
+	defer os.RemoveAll(dir)
+
+	out, err := suite.newStandInManager().Sync(context.TODO(), dir, "reports", &SyncInput{
+		Direction: SyncRemoteToLocal,
+		FailFast:  true,
+	})
+	assert.True(suite.T(), IsUnsafeKey(err))
+	assert.Equal(suite.T(), "Sync", err.(*Error).Op)
+	assert.Equal(suite.T(), "reports/../escape.csv", err.(*Error).Key)
+	assert.Equal(suite.T(), err, out.Actions[0].Err)
+	assert.NoError(suite.T(), out.Actions[1].Err)
+
+	_, err = os.Stat(filepath.Join(dir, "a.csv"))
+	assert.True(suite.T(), os.IsNotExist(err))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Sync_DeleteRemote_Error() {
+	dir := suite.newUploadDir("a.csv")
+	defer os.RemoveAll(dir)
+
+	suite.s3Server.PutObject(standInBucket, "a.csv", &test.S3Object{Body: []byte("a.csv")})
+	suite.s3Server.PutObject(standInBucket, "old.csv", &test.S3Object{Body: []byte("old")})
+
+	manager := suite.newStandInManager().(*AwsManager)
+	manager.awsS3 = &failingDeleteS3{S3API: manager.awsS3}
+
+	out, err := manager.Sync(context.TODO(), dir, "", &SyncInput{Direction: SyncLocalToRemote, Delete: true})
+	assert.True(suite.T(), IsRetryable(err))
+	assert.Equal(suite.T(), []string{"delete old.csv"}, suite.syncOps(out))
+	assert.Equal(suite.T(), err, out.Actions[0].Err)
+
+	_, ok := suite.s3Server.Object(standInBucket, "old.csv")
+	assert.True(suite.T(), ok)
+}
@@ -0,0 +1,788 @@
+package test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	s3MetadataHeaderPrefix = "X-Amz-Meta-"
+	s3DefaultMaxKeys       = 1000
+	s3TimeFormat           = "2006-01-02T15:04:05.000Z"
+)
+
+// S3Server is an in-memory stand-in of an S3 compatible store addressed in path style.
+// It implements the object, listing, batch delete, copy, multipart upload and tagging requests used by the manager.
+type S3Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	buckets map[string]map[string]*S3Object
+	uploads map[string]*s3Upload
+	seq     int
+}
+
+type S3Object struct {
+	Body               []byte
+	CacheControl       string
+	ContentDisposition string
+	ContentEncoding    string
+	ContentLanguage    string
+	ContentType        string
+	ETag               string
+	LastModified       time.Time
+	Metadata           map[string]string
+	StorageClass       string
+	Tagging            string
+}
+
+type s3Upload struct {
+	bucket string
+	key    string
+	object *S3Object
+	parts  map[int64][]byte
+}
+
+type s3Error struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string
+	Message   string
+	RequestId string
+}
+
+type s3ListBucketResult struct {
+	XMLName               xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name                  string
+	Prefix                string
+	Delimiter             string `xml:",omitempty"`
+	MaxKeys               int
+	KeyCount              int
+	IsTruncated           bool
+	ContinuationToken     string `xml:",omitempty"`
+	NextContinuationToken string `xml:",omitempty"`
+	StartAfter            string `xml:",omitempty"`
+	Contents              []s3ListObject
+	CommonPrefixes        []s3CommonPrefix
+}
+
+type s3ListObject struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int
+	StorageClass string
+}
+
+type s3CommonPrefix struct {
+	Prefix string
+}
+
+type s3Delete struct {
+	Objects []struct {
+		Key       string
+		VersionId string
+	} `xml:"Object"`
+	Quiet bool
+}
+
+type s3DeleteResult struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ DeleteResult"`
+	Deleted []s3DeletedObject
+}
+
+type s3DeletedObject struct {
+	Key       string
+	VersionId string `xml:",omitempty"`
+}
+
+type s3CopyResult struct {
+	XMLName      xml.Name
+	ETag         string
+	LastModified string
+}
+
+type s3InitiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ InitiateMultipartUploadResult"`
+	Bucket   string
+	Key      string
+	UploadId string
+}
+
+type s3CompleteMultipartUpload struct {
+	Parts []struct {
+		PartNumber int64
+		ETag       string
+	} `xml:"Part"`
+}
+
+type s3CompleteMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CompleteMultipartUploadResult"`
+	Location string
+	Bucket   string
+	Key      string
+	ETag     string
+}
+
+type s3Tagging struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ Tagging"`
+	Tags    []s3Tag  `xml:"TagSet>Tag"`
+}
+
+type s3Tag struct {
+	Key   string
+	Value string
+}
+
+// NewS3Server starts the server with the given buckets created.
+func NewS3Server(buckets ...string) *S3Server {
+	s := &S3Server{
+		buckets: make(map[string]map[string]*S3Object),
+		uploads: make(map[string]*s3Upload),
+	}
+
+	for _, bucket := range buckets {
+		s.CreateBucket(bucket)
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+func (s *S3Server) CreateBucket(bucket string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[bucket]; !ok {
+		s.buckets[bucket] = make(map[string]*S3Object)
+	}
+}
+
+// PutObject stores the object bypassing the HTTP API.
+func (s *S3Server) PutObject(bucket, key string, object *S3Object) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if object.ETag == "" {
+		object.ETag = s3ETag(object.Body)
+	}
+
+	if object.LastModified.IsZero() {
+		object.LastModified = time.Now().UTC().Truncate(time.Second)
+	}
+
+	s.buckets[bucket][key] = object
+}
+
+// Object returns a copy of the stored object.
+func (s *S3Server) Object(bucket, key string) (*S3Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	object, ok := s.buckets[bucket][key]
+
+	if !ok {
+		return nil, false
+	}
+
+	out := *object
+	return &out, true
+}
+
+// Keys returns the sorted keys of the objects stored in the bucket.
+func (s *S3Server) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.keys(bucket)
+}
+
+// Uploads returns the number of multipart uploads which were neither completed nor aborted.
+func (s *S3Server) Uploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.uploads)
+}
+
+func (s *S3Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	w.Header().Set("X-Amz-Request-Id", "request"+strconv.Itoa(s.seq))
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	bucket := parts[0]
+	objects, ok := s.buckets[bucket]
+
+	if !ok {
+		s.error(w, r, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+
+	if len(parts) == 1 || parts[1] == "" {
+		s.handleBucket(w, r, bucket, objects)
+		return
+	}
+
+	key := parts[1]
+	query := r.URL.Query()
+
+	switch {
+	case r.Method == http.MethodPut && query.Get("uploadId") != "":
+		s.uploadPart(w, r, bucket, key)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		s.copyObject(w, r, bucket, key)
+	case r.Method == http.MethodPut:
+		s.putObject(w, r, bucket, key)
+	case r.Method == http.MethodPost && hasQuery(query, "uploads"):
+		s.createMultipartUpload(w, r, bucket, key)
+	case r.Method == http.MethodPost && query.Get("uploadId") != "":
+		s.completeMultipartUpload(w, r, bucket, key)
+	case r.Method == http.MethodDelete && query.Get("uploadId") != "":
+		delete(s.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && hasQuery(query, "tagging"):
+		s.getObjectTagging(w, r, objects, key)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.getObject(w, r, objects, key)
+	default:
+		s.error(w, r, http.StatusNotImplemented, "NotImplemented", "The request is not implemented")
+	}
+}
+
+func (s *S3Server) handleBucket(w http.ResponseWriter, r *http.Request, bucket string, objects map[string]*S3Object) {
+	query := r.URL.Query()
+
+	switch {
+	case r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPost && hasQuery(query, "delete"):
+		s.deleteObjects(w, r, objects)
+	case r.Method == http.MethodGet && query.Get("list-type") == "2":
+		s.listObjectsV2(w, r, bucket)
+	default:
+		s.error(w, r, http.StatusNotImplemented, "NotImplemented", "The request is not implemented")
+	}
+}
+
+func (s *S3Server) putObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	body, ok := s.readBody(w, r)
+
+	if !ok {
+		return
+	}
+
+	object := newS3Object(r.Header)
+	object.Body = body
+	object.ETag = s3ETag(body)
+	object.LastModified = time.Now().UTC().Truncate(time.Second)
+	s.buckets[bucket][key] = object
+
+	w.Header().Set("ETag", object.ETag)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *S3Server) getObject(w http.ResponseWriter, r *http.Request, objects map[string]*S3Object, key string) {
+	object, ok := objects[key]
+
+	if !ok {
+		s.error(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+
+	if v := r.Header.Get("If-Match"); v != "" && v != object.ETag {
+		s.error(w, r, http.StatusPreconditionFailed, "PreconditionFailed", "At least one of the preconditions you specified did not hold")
+		return
+	}
+
+	if v := r.Header.Get("If-None-Match"); v != "" && v == object.ETag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, status, contentRange, ok := s3Range(r.Header.Get("Range"), object.Body)
+
+	if !ok {
+		s.error(w, r, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The requested range is not satisfiable")
+		return
+	}
+
+	h := w.Header()
+	h.Set("Accept-Ranges", "bytes")
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	h.Set("ETag", object.ETag)
+	h.Set("Last-Modified", object.LastModified.Format(http.TimeFormat))
+	setHeader(h, "Cache-Control", object.CacheControl)
+	setHeader(h, "Content-Disposition", object.ContentDisposition)
+	setHeader(h, "Content-Encoding", object.ContentEncoding)
+	setHeader(h, "Content-Language", object.ContentLanguage)
+	setHeader(h, "Content-Range", contentRange)
+	setHeader(h, "X-Amz-Storage-Class", object.StorageClass)
+
+	if object.ContentType != "" {
+		h.Set("Content-Type", object.ContentType)
+	} else {
+		h.Set("Content-Type", "binary/octet-stream")
+	}
+
+	for k, v := range object.Metadata {
+		h.Set(s3MetadataHeaderPrefix+k, v)
+	}
+
+	w.WriteHeader(status)
+
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(body)
+	}
+}
+
+func (s *S3Server) copyObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	source, ok := s.copySource(w, r)
+
+	if !ok {
+		return
+	}
+
+	object := *source
+
+	if r.Header.Get("X-Amz-Metadata-Directive") == "REPLACE" {
+		replaced := newS3Object(r.Header)
+		replaced.Tagging = object.Tagging
+		object = *replaced
+	}
+
+	if r.Header.Get("X-Amz-Tagging-Directive") == "REPLACE" {
+		object.Tagging = r.Header.Get("X-Amz-Tagging")
+	}
+
+	if v := r.Header.Get("X-Amz-Storage-Class"); v != "" {
+		object.StorageClass = v
+	}
+
+	object.Body = append([]byte(nil), source.Body...)
+	object.ETag = s3ETag(object.Body)
+	object.LastModified = time.Now().UTC().Truncate(time.Second)
+	s.buckets[bucket][key] = &object
+
+	s.xml(w, http.StatusOK, &s3CopyResult{
+		XMLName:      xml.Name{Space: "http://s3.amazonaws.com/doc/2006-03-01/", Local: "CopyObjectResult"},
+		ETag:         object.ETag,
+		LastModified: object.LastModified.Format(s3TimeFormat),
+	})
+}
+
+func (s *S3Server) copySource(w http.ResponseWriter, r *http.Request) (*S3Object, bool) {
+	source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+
+	if err != nil {
+		s.error(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid copy source")
+		return nil, false
+	}
+
+	if i := strings.Index(source, "?"); i >= 0 {
+		source = source[:i]
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(source, "/"), "/", 2)
+
+	if len(parts) != 2 {
+		s.error(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid copy source")
+		return nil, false
+	}
+
+	object, ok := s.buckets[parts[0]][parts[1]]
+
+	if !ok {
+		s.error(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return nil, false
+	}
+
+	return object, true
+}
+
+func (s *S3Server) deleteObjects(w http.ResponseWriter, r *http.Request, objects map[string]*S3Object) {
+	in := &s3Delete{}
+
+	if err := xml.NewDecoder(r.Body).Decode(in); err != nil {
+		s.error(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+
+	out := &s3DeleteResult{}
+
+	for _, v := range in.Objects {
+		delete(objects, v.Key)
+
+		if !in.Quiet {
+			out.Deleted = append(out.Deleted, s3DeletedObject{Key: v.Key, VersionId: v.VersionId})
+		}
+	}
+
+	s.xml(w, http.StatusOK, out)
+}
+
+func (s *S3Server) listObjectsV2(w http.ResponseWriter, r *http.Request, bucket string) {
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	token := query.Get("continuation-token")
+	maxKeys := s3DefaultMaxKeys
+
+	if v, err := strconv.Atoi(query.Get("max-keys")); err == nil && v >= 0 {
+		maxKeys = v
+	}
+
+	after := query.Get("start-after")
+
+	if token != "" {
+		after = token
+	}
+
+	out := &s3ListBucketResult{
+		Name:              bucket,
+		Prefix:            prefix,
+		Delimiter:         delimiter,
+		MaxKeys:           maxKeys,
+		ContinuationToken: token,
+		StartAfter:        query.Get("start-after"),
+	}
+	last := ""
+
+	for _, key := range s.keys(bucket) {
+		if !strings.HasPrefix(key, prefix) || key <= after {
+			continue
+		}
+
+		if delimiter != "" && strings.HasSuffix(after, delimiter) && strings.HasPrefix(key, after) {
+			continue
+		}
+
+		entry := key
+
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				entry = key[:len(prefix)+i+len(delimiter)]
+			}
+		}
+
+		if entry == last {
+			continue
+		}
+
+		if out.KeyCount == maxKeys {
+			out.IsTruncated = true
+			out.NextContinuationToken = last
+			break
+		}
+
+		if entry != key {
+			out.CommonPrefixes = append(out.CommonPrefixes, s3CommonPrefix{Prefix: entry})
+		} else {
+			object := s.buckets[bucket][key]
+			storageClass := object.StorageClass
+
+			if storageClass == "" {
+				storageClass = "STANDARD"
+			}
+
+			out.Contents = append(out.Contents, s3ListObject{
+				Key:          key,
+				LastModified: object.LastModified.Format(s3TimeFormat),
+				ETag:         object.ETag,
+				Size:         len(object.Body),
+				StorageClass: storageClass,
+			})
+		}
+
+		out.KeyCount++
+		last = entry
+	}
+
+	s.xml(w, http.StatusOK, out)
+}
+
+func (s *S3Server) createMultipartUpload(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	uploadId := fmt.Sprintf("upload%d", s.seq)
+	s.uploads[uploadId] = &s3Upload{
+		bucket: bucket,
+		key:    key,
+		object: newS3Object(r.Header),
+		parts:  make(map[int64][]byte),
+	}
+
+	s.xml(w, http.StatusOK, &s3InitiateMultipartUploadResult{Bucket: bucket, Key: key, UploadId: uploadId})
+}
+
+func (s *S3Server) uploadPart(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	upload, ok := s.upload(w, r, bucket, key)
+
+	if !ok {
+		return
+	}
+
+	partNumber, err := strconv.ParseInt(r.URL.Query().Get("partNumber"), 10, 64)
+
+	if err != nil {
+		s.error(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid part number")
+		return
+	}
+
+	if r.Header.Get("X-Amz-Copy-Source") == "" {
+		body, ok := s.readBody(w, r)
+
+		if !ok {
+			return
+		}
+
+		upload.parts[partNumber] = body
+		w.Header().Set("ETag", s3ETag(body))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	source, ok := s.copySource(w, r)
+
+	if !ok {
+		return
+	}
+
+	body := source.Body
+
+	if v := r.Header.Get("X-Amz-Copy-Source-Range"); v != "" {
+		if body, _, _, ok = s3Range(v, source.Body); !ok {
+			s.error(w, r, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The requested range is not satisfiable")
+			return
+		}
+	}
+
+	upload.parts[partNumber] = append([]byte(nil), body...)
+
+	s.xml(w, http.StatusOK, &s3CopyResult{
+		XMLName:      xml.Name{Space: "http://s3.amazonaws.com/doc/2006-03-01/", Local: "CopyPartResult"},
+		ETag:         s3ETag(body),
+		LastModified: time.Now().UTC().Format(s3TimeFormat),
+	})
+}
+
+func (s *S3Server) completeMultipartUpload(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	upload, ok := s.upload(w, r, bucket, key)
+
+	if !ok {
+		return
+	}
+
+	in := &s3CompleteMultipartUpload{}
+
+	if err := xml.NewDecoder(r.Body).Decode(in); err != nil {
+		s.error(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+
+	body := &bytes.Buffer{}
+	sums := make([]byte, 0, md5.Size*len(in.Parts))
+
+	for i, part := range in.Parts {
+		data, ok := upload.parts[part.PartNumber]
+
+		if !ok || part.ETag != s3ETag(data) || (i > 0 && part.PartNumber <= in.Parts[i-1].PartNumber) {
+			s.error(w, r, http.StatusBadRequest, "InvalidPart", "One or more of the specified parts could not be found")
+			return
+		}
+
+		sum := md5.Sum(data)
+		sums = append(sums, sum[:]...)
+		body.Write(data)
+	}
+
+	sum := md5.Sum(sums)
+	object := upload.object
+	object.Body = body.Bytes()
+	object.ETag = fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(sum[:]), len(in.Parts))
+	object.LastModified = time.Now().UTC().Truncate(time.Second)
+	s.buckets[bucket][key] = object
+	delete(s.uploads, r.URL.Query().Get("uploadId"))
+
+	s.xml(w, http.StatusOK, &s3CompleteMultipartUploadResult{
+		Location: s.URL + "/" + bucket + "/" + key,
+		Bucket:   bucket,
+		Key:      key,
+		ETag:     object.ETag,
+	})
+}
+
+func (s *S3Server) upload(w http.ResponseWriter, r *http.Request, bucket, key string) (*s3Upload, bool) {
+	upload, ok := s.uploads[r.URL.Query().Get("uploadId")]
+
+	if !ok || upload.bucket != bucket || upload.key != key {
+		s.error(w, r, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist")
+		return nil, false
+	}
+
+	return upload, true
+}
+
+func (s *S3Server) getObjectTagging(w http.ResponseWriter, r *http.Request, objects map[string]*S3Object, key string) {
+	object, ok := objects[key]
+
+	if !ok {
+		s.error(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+
+	out := &s3Tagging{Tags: []s3Tag{}}
+	values, _ := url.ParseQuery(object.Tagging)
+
+	for k := range values {
+		out.Tags = append(out.Tags, s3Tag{Key: k, Value: values.Get(k)})
+	}
+
+	sort.Slice(out.Tags, func(i, j int) bool {
+		return out.Tags[i].Key < out.Tags[j].Key
+	})
+
+	s.xml(w, http.StatusOK, out)
+}
+
+func (s *S3Server) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		s.error(w, r, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return nil, false
+	}
+
+	if v := r.Header.Get("Content-MD5"); v != "" && v != s3ContentMD5(body) {
+		s.error(w, r, http.StatusBadRequest, "BadDigest", "The Content-MD5 you specified did not match what we received.")
+		return nil, false
+	}
+
+	return body, true
+}
+
+func (s *S3Server) keys(bucket string) []string {
+	keys := make([]string, 0, len(s.buckets[bucket]))
+
+	for k := range s.buckets[bucket] {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (s *S3Server) xml(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func (s *S3Server) error(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+
+	s.xml(w, status, &s3Error{Code: code, Message: message, RequestId: w.Header().Get("X-Amz-Request-Id")})
+}
+
+func newS3Object(h http.Header) *S3Object {
+	object := &S3Object{
+		CacheControl:       h.Get("Cache-Control"),
+		ContentDisposition: h.Get("Content-Disposition"),
+		ContentEncoding:    h.Get("Content-Encoding"),
+		ContentLanguage:    h.Get("Content-Language"),
+		ContentType:        h.Get("Content-Type"),
+		Metadata:           make(map[string]string),
+		StorageClass:       h.Get("X-Amz-Storage-Class"),
+		Tagging:            h.Get("X-Amz-Tagging"),
+	}
+
+	for k, v := range h {
+		if strings.HasPrefix(k, s3MetadataHeaderPrefix) && len(v) > 0 {
+			object.Metadata[k[len(s3MetadataHeaderPrefix):]] = v[0]
+		}
+	}
+
+	return object
+}
+
+// s3Range returns the part of the body selected by a "bytes=first-last", "bytes=first-" or "bytes=-suffix" range.
+func s3Range(header string, body []byte) ([]byte, int, string, bool) {
+	if header == "" {
+		return body, http.StatusOK, "", true
+	}
+
+	size := int64(len(body))
+	spec := strings.SplitN(strings.TrimPrefix(header, "bytes="), "-", 2)
+
+	if len(spec) != 2 {
+		return nil, 0, "", false
+	}
+
+	first, errFirst := strconv.ParseInt(spec[0], 10, 64)
+	last, errLast := strconv.ParseInt(spec[1], 10, 64)
+
+	switch {
+	case errFirst != nil && errLast == nil:
+		first, last = size-last, size-1
+	case errFirst == nil && errLast != nil:
+		last = size - 1
+	case errFirst != nil:
+		return nil, 0, "", false
+	}
+
+	if first < 0 {
+		first = 0
+	}
+
+	if last >= size {
+		last = size - 1
+	}
+
+	if first > last {
+		return nil, 0, "", false
+	}
+
+	return body[first : last+1], http.StatusPartialContent, fmt.Sprintf("bytes %d-%d/%d", first, last, size), true
+}
+
+func s3ETag(body []byte) string {
+	sum := md5.Sum(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func s3ContentMD5(body []byte) string {
+	sum := md5.Sum(body)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func setHeader(h http.Header, key, value string) {
+	if value != "" {
+		h.Set(key, value)
+	}
+}
+
+func hasQuery(query url.Values, key string) bool {
+	_, ok := query[key]
+	return ok
+}
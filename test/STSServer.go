@@ -0,0 +1,95 @@
+package test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// STSServer is a local stand-in of the STS endpoint which answers AssumeRole requests
+// with unique temporary credentials expiring after TTL, or after the requested duration when TTL is zero.
+type STSServer struct {
+	*httptest.Server
+	TTL time.Duration
+
+	mu            sync.Mutex
+	requests      []url.Values
+	authorization []string
+}
+
+type stsCredentials struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+}
+
+type stsAssumeRoleResponse struct {
+	XMLName     xml.Name       `xml:"https://sts.amazonaws.com/doc/2011-06-15/ AssumeRoleResponse"`
+	Credentials stsCredentials `xml:"AssumeRoleResult>Credentials"`
+	Arn         string         `xml:"AssumeRoleResult>AssumedRoleUser>Arn"`
+	RoleId      string         `xml:"AssumeRoleResult>AssumedRoleUser>AssumedRoleId"`
+	RequestId   string         `xml:"ResponseMetadata>RequestId"`
+}
+
+func NewSTSServer() *STSServer {
+	s := &STSServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// Requests returns the form values of the received AssumeRole requests.
+func (s *STSServer) Requests() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]url.Values(nil), s.requests...)
+}
+
+// Authorization returns the Authorization headers of the received AssumeRole requests.
+func (s *STSServer) Authorization() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.authorization...)
+}
+
+func (s *STSServer) handle(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("Action") != "AssumeRole" {
+		http.Error(w, "unsupported request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, r.PostForm)
+	s.authorization = append(s.authorization, r.Header.Get("Authorization"))
+	n := len(s.requests)
+	ttl := s.TTL
+	s.mu.Unlock()
+
+	if ttl == 0 {
+		seconds, _ := strconv.Atoi(r.PostForm.Get("DurationSeconds"))
+		ttl = time.Duration(seconds) * time.Second
+	}
+
+	rsp := &stsAssumeRoleResponse{
+		Credentials: stsCredentials{
+			AccessKeyId:     fmt.Sprintf("ASIA%d", n),
+			SecretAccessKey: fmt.Sprintf("secret%d", n),
+			SessionToken:    fmt.Sprintf("token%d", n),
+			Expiration:      time.Now().UTC().Add(ttl),
+		},
+		Arn:       r.PostForm.Get("RoleArn") + "/" + r.PostForm.Get("RoleSessionName"),
+		RoleId:    "AROA" + strconv.Itoa(n),
+		RequestId: "request" + strconv.Itoa(n),
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+	_ = xml.NewEncoder(w).Encode(rsp)
+}
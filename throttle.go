@@ -0,0 +1,200 @@
+package aws_manager
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// rateLimiterBurstDivisor sets the bucket size to a tenth of a second of traffic,
+	// which keeps the rate smooth while reads and writes stay reasonably large.
+	rateLimiterBurstDivisor = 10
+)
+
+// rateLimiter is a token bucket which refills at rate bytes per second up to burst bytes.
+// Callers reserve the bytes they have transferred and sleep until the reservation is covered,
+// so concurrent transfers sharing the limiter split the rate between them.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+type throttledReader struct {
+	io.Reader
+	ctx      context.Context
+	limiters []*rateLimiter
+}
+
+type throttledReadCloser struct {
+	throttledReader
+	io.Closer
+}
+
+type throttledWriterAt struct {
+	io.WriterAt
+	ctx      context.Context
+	limiters []*rateLimiter
+}
+
+func newRateLimiter(bytesPerSecond int64) *rateLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+
+	burst := bytesPerSecond / rateLimiterBurstDivisor
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		rate:   float64(bytesPerSecond),
+		burst:  int(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait takes n bytes from the bucket and blocks until the bucket has covered them or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	l.last = now
+
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+
+	l.tokens -= float64(n)
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// transferLimiters returns the limiter of the manager followed by a limiter of the call, if any.
+func (m *AwsManager) transferLimiters(bytesPerSecond int64) []*rateLimiter {
+	var limiters []*rateLimiter
+
+	if m.limiter != nil {
+		limiters = append(limiters, m.limiter)
+	}
+
+	if limiter := newRateLimiter(bytesPerSecond); limiter != nil {
+		limiters = append(limiters, limiter)
+	}
+
+	return limiters
+}
+
+func throttleReader(ctx context.Context, r io.Reader, limiters []*rateLimiter) io.Reader {
+	if len(limiters) == 0 || r == nil {
+		return r
+	}
+
+	return &throttledReader{Reader: r, ctx: ctx, limiters: limiters}
+}
+
+func throttleReadCloser(ctx context.Context, r io.ReadCloser, limiters []*rateLimiter) io.ReadCloser {
+	if len(limiters) == 0 || r == nil {
+		return r
+	}
+
+	return &throttledReadCloser{throttledReader: throttledReader{Reader: r, ctx: ctx, limiters: limiters}, Closer: r}
+}
+
+func throttleWriterAt(ctx context.Context, w io.WriterAt, limiters []*rateLimiter) io.WriterAt {
+	if len(limiters) == 0 {
+		return w
+	}
+
+	return &throttledWriterAt{WriterAt: w, ctx: ctx, limiters: limiters}
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if max := maxBurst(r.limiters); len(p) > max {
+		p = p[:max]
+	}
+
+	n, err := r.Reader.Read(p)
+
+	if waitErr := waitLimiters(r.ctx, r.limiters, n); waitErr != nil {
+		return n, waitErr
+	}
+
+	return n, err
+}
+
+// WriteAt writes p in chunks no larger than the bucket, waiting before each chunk.
+func (w *throttledWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	max := maxBurst(w.limiters)
+	written := 0
+
+	for len(p) > 0 {
+		chunk := p
+
+		if len(chunk) > max {
+			chunk = chunk[:max]
+		}
+
+		if err := waitLimiters(w.ctx, w.limiters, len(chunk)); err != nil {
+			return written, err
+		}
+
+		n, err := w.WriterAt.WriteAt(chunk, off)
+		written += n
+
+		if err != nil {
+			return written, err
+		}
+
+		p = p[n:]
+		off += int64(n)
+	}
+
+	return written, nil
+}
+
+func waitLimiters(ctx context.Context, limiters []*rateLimiter, n int) error {
+	for _, l := range limiters {
+		if err := l.wait(ctx, n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func maxBurst(limiters []*rateLimiter) int {
+	max := limiters[0].burst
+
+	for _, l := range limiters[1:] {
+		if l.burst < max {
+			max = l.burst
+		}
+	}
+
+	return max
+}
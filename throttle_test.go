@@ -0,0 +1,157 @@
+package aws_manager
+
+import (
+	"bytes"
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+const (
+	throttleTestLimit = 100 * 1024
+	throttleTestBurst = throttleTestLimit / rateLimiterBurstDivisor
+)
+
+// assertThrottled checks the elapsed time of transferring size bytes at throttleTestLimit,
+// the first burst passes without waiting.
+func (suite *AwsManagerTestSuite) assertThrottled(size int, elapsed time.Duration) {
+	expected := time.Duration(float64(size-throttleTestBurst) / throttleTestLimit * float64(time.Second))
+
+	assert.True(suite.T(), elapsed >= expected*8/10, "elapsed %s, expected %s", elapsed, expected)
+	assert.True(suite.T(), elapsed <= expected*16/10, "elapsed %s, expected %s", elapsed, expected)
+}
+
+func (suite *AwsManagerTestSuite) TestRateLimiter_HoldsRate() {
+	limiter := newRateLimiter(throttleTestLimit)
+	assert.Equal(suite.T(), throttleTestBurst, limiter.burst)
+
+	start := time.Now()
+
+	for i := 0; i < 6; i++ {
+		assert.NoError(suite.T(), limiter.wait(context.TODO(), throttleTestBurst))
+	}
+
+	suite.assertThrottled(6*throttleTestBurst, time.Since(start))
+}
+
+func (suite *AwsManagerTestSuite) TestRateLimiter_ContextCanceled() {
+	limiter := newRateLimiter(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.Equal(suite.T(), context.Canceled, limiter.wait(ctx, 100))
+	assert.Nil(suite.T(), newRateLimiter(0))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Upload_BandwidthLimit_Ok() {
+	body := bytes.Repeat([]byte("a"), 4*throttleTestBurst)
+	start := time.Now()
+
+	_, err := suite.newStandInManager().Upload(context.TODO(), &UploadInput{
+		Body:           bytes.NewReader(body),
+		FileName:       fileName,
+		BandwidthLimit: throttleTestLimit,
+	})
+	assert.NoError(suite.T(), err)
+	suite.assertThrottled(len(body), time.Since(start))
+
+	object, ok := suite.s3Server.Object(standInBucket, fileName)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), body, object.Body)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Upload_BandwidthLimit_MaxUploadParts_Ok() {
+	body := bytes.Repeat([]byte("a"), 12*1024*1024)
+
+	_, err := suite.newStandInManager(UploadMaxParts(2), BandwidthLimit(1<<40)).Upload(context.TODO(), &UploadInput{
+		Body:     bytes.NewReader(body),
+		FileName: fileName,
+	})
+	assert.NoError(suite.T(), err)
+
+	object, ok := suite.s3Server.Object(standInBucket, fileName)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), body, object.Body)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_BandwidthLimit_SharedByTransfers() {
+	manager := suite.newStandInManager(BandwidthLimit(throttleTestLimit))
+	suite.s3Server.PutObject(standInBucket, "download.bin", &test.S3Object{Body: make([]byte, 3*throttleTestBurst)})
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		_, err := manager.Upload(context.TODO(), &UploadInput{
+			Body:     bytes.NewReader(make([]byte, 3*throttleTestBurst)),
+			FileName: "upload.bin",
+		})
+		assert.NoError(suite.T(), err)
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		_, err := manager.DownloadTo(context.TODO(), aws.NewWriteAtBuffer(nil), &DownloadInput{FileName: "download.bin"})
+		assert.NoError(suite.T(), err)
+	}()
+
+	wg.Wait()
+	suite.assertThrottled(6*throttleTestBurst, time.Since(start))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_DownloadTo_BandwidthLimit_Ok() {
+	content := bytes.Repeat([]byte("a"), 4*throttleTestBurst)
+	suite.s3Server.PutObject(standInBucket, fileName, &test.S3Object{Body: content})
+	buf := aws.NewWriteAtBuffer(nil)
+	start := time.Now()
+
+	out, err := suite.newStandInManager().DownloadTo(context.TODO(), buf, &DownloadInput{
+		FileName:       fileName,
+		BandwidthLimit: throttleTestLimit,
+	})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(len(content)), out.Size)
+	assert.Equal(suite.T(), content, buf.Bytes())
+	suite.assertThrottled(len(content), time.Since(start))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Open_BandwidthLimit_Ok() {
+	content := bytes.Repeat([]byte("a"), 4*throttleTestBurst)
+	suite.s3Server.PutObject(standInBucket, fileName, &test.S3Object{Body: content})
+	start := time.Now()
+
+	body, _, err := suite.newStandInManager().Open(context.TODO(), &DownloadInput{
+		FileName:       fileName,
+		BandwidthLimit: throttleTestLimit,
+	})
+	assert.NoError(suite.T(), err)
+
+	b, err := ioutil.ReadAll(body)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), body.Close())
+	assert.Equal(suite.T(), content, b)
+	suite.assertThrottled(len(content), time.Since(start))
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_Upload_BandwidthLimit_ContextCanceled() {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := suite.newStandInManager().Upload(ctx, &UploadInput{
+		Body:           bytes.NewReader(make([]byte, 10*throttleTestBurst)),
+		FileName:       fileName,
+		BandwidthLimit: throttleTestLimit,
+	})
+	assert.Error(suite.T(), err)
+
+	_, ok := suite.s3Server.Object(standInBucket, fileName)
+	assert.False(suite.T(), ok)
+}
@@ -0,0 +1,123 @@
+package aws_manager
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHTTPDialTimeout         = 30 * time.Second
+	defaultHTTPKeepAlive           = 30 * time.Second
+	defaultHTTPMaxIdleConns        = 100
+	defaultHTTPIdleConnTimeout     = 90 * time.Second
+	defaultHTTPTLSHandshakeTimeout = 10 * time.Second
+	defaultHTTPExpectContinue      = time.Second
+
+	operationTimeoutHandlerName = "aws_manager.OperationTimeout"
+)
+
+// newHTTPClient returns the HTTP client configured by the transport options,
+// or nil when none of them is set and the SDK default client should be used.
+// A CA bundle requires a dedicated client as the SDK installs it into the transport of the client.
+func newHTTPClient(opts *Options) (*http.Client, error) {
+	if !opts.hasTransportSettings() {
+		return nil, nil
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   defaultHTTPDialTimeout,
+			KeepAlive: defaultHTTPKeepAlive,
+			DualStack: true,
+		}).DialContext,
+		MaxIdleConns:          defaultHTTPMaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       defaultHTTPIdleConnTimeout,
+		TLSHandshakeTimeout:   defaultHTTPTLSHandshakeTimeout,
+		ExpectContinueTimeout: defaultHTTPExpectContinue,
+	}
+
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+	}
+
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+
+	if opts.ProxyURL != "" {
+		proxy, err := url.Parse(opts.ProxyURL)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %v", urlParseReason(err))
+		}
+
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// urlParseReason drops the URL from a parse error, it may hold a password.
+func urlParseReason(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+
+	return err
+}
+
+func (opts *Options) hasTransportSettings() bool {
+	return opts.MaxIdleConns > 0 || opts.MaxIdleConnsPerHost > 0 || opts.MaxConnsPerHost > 0 ||
+		opts.IdleConnTimeout > 0 || opts.ProxyURL != "" || opts.CABundle != ""
+}
+
+// withOperationTimeout limits each API operation of the client, including its retries, to timeout.
+// Presigned requests are not affected. The response body of GetObject is bound to the same deadline
+// and releases it when closed.
+func withOperationTimeout(client *s3.S3, timeout time.Duration) {
+	client.Handlers.Build.PushFrontNamed(request.NamedHandler{
+		Name: operationTimeoutHandlerName,
+		Fn: func(r *request.Request) {
+			if r.ExpireTime > 0 {
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			r.SetContext(ctx)
+			r.Handlers.Complete.PushBack(func(r *request.Request) {
+				out, ok := r.Data.(*s3.GetObjectOutput)
+
+				if r.Error != nil || !ok || out.Body == nil {
+					cancel()
+					return
+				}
+
+				out.Body = &cancelReadCloser{ReadCloser: out.Body, cancel: cancel}
+			})
+		},
+	})
+}
+
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+func (r *cancelReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.cancel)
+
+	return err
+}
@@ -0,0 +1,127 @@
+package aws_manager
+
+import (
+	"context"
+	"encoding/pem"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"time"
+)
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_WithoutTransportOptions_DefaultHTTPClient() {
+	client, err := newHTTPClient(&Options{})
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), client)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_WithTransportOptions_Ok() {
+	manager := suite.newStandInManager(
+		MaxIdleConns(50),
+		MaxIdleConnsPerHost(20),
+		MaxConnsPerHost(30),
+		IdleConnTimeout(time.Minute),
+		ProxyURL("http://proxy.local:3128"),
+	)
+
+	c := manager.(*AwsManager).awsS3.(*s3.S3)
+	transport, ok := c.Config.HTTPClient.Transport.(*http.Transport)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), 50, transport.MaxIdleConns)
+	assert.Equal(suite.T(), 20, transport.MaxIdleConnsPerHost)
+	assert.Equal(suite.T(), 30, transport.MaxConnsPerHost)
+	assert.Equal(suite.T(), time.Minute, transport.IdleConnTimeout)
+
+	req, err := http.NewRequest(http.MethodGet, "https://s3.eu-west-1.amazonaws.com/", nil)
+	assert.NoError(suite.T(), err)
+
+	proxy, err := transport.Proxy(req)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "http://proxy.local:3128", proxy.String())
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_WithTransportEnvVariables_Ok() {
+	defer suite.setEnv("AWS_HTTP_MAX_CONNS_PER_HOST", "8")()
+	defer suite.setEnv("AWS_HTTP_IDLE_CONN_TIMEOUT", "15s")()
+
+	manager, err := New()
+	assert.NoError(suite.T(), err)
+
+	transport := manager.(*AwsManager).awsS3.(*s3.S3).Config.HTTPClient.Transport.(*http.Transport)
+	assert.Equal(suite.T(), 8, transport.MaxConnsPerHost)
+	assert.Equal(suite.T(), 15*time.Second, transport.IdleConnTimeout)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_InvalidProxyURL_Error() {
+	manager, err := New(Bucket("bucket"), ProxyURL("http://[::1"))
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), manager)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_CABundle_Ok() {
+	server := httptest.NewTLSServer(suite.s3Server.Config.Handler)
+	defer server.Close()
+
+	suite.s3Server.PutObject(standInBucket, fileName, &test.S3Object{Body: []byte("content")})
+
+	bundle := filepath.Join(suite.newDownloadDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.NoError(suite.T(), ioutil.WriteFile(bundle, cert, 0600))
+
+	manager := suite.newStandInManager(Endpoint(server.URL), CABundle(bundle), MaxRetries(0))
+	exists, err := manager.Exists(context.TODO(), fileName)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), exists)
+
+	defer suite.unsetEnv("AWS_CA_BUNDLE")()
+
+	manager = suite.newStandInManager(Endpoint(server.URL), MaxRetries(0))
+	_, err = manager.Exists(context.TODO(), fileName)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_NewManager_CABundleNotExist_Error() {
+	manager, err := New(Bucket("bucket"), CABundle("./not_exist_ca.pem"))
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), manager)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_OperationTimeout_Error() {
+	handler := suite.s3Server.Config.Handler
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	manager := suite.newStandInManager(Endpoint(server.URL), OperationTimeout(20*time.Millisecond), MaxRetries(0))
+	_, err := manager.Stat(context.TODO(), &StatInput{FileName: fileName})
+	assert.Error(suite.T(), err)
+
+	aerr, ok := err.(awserr.Error)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), request.CanceledErrorCode, aerr.Code())
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_OperationTimeout_StreamReadable() {
+	suite.s3Server.PutObject(standInBucket, fileName, &test.S3Object{Body: []byte("content")})
+
+	manager := suite.newStandInManager(OperationTimeout(time.Second))
+	body, _, err := manager.Open(context.TODO(), &DownloadInput{FileName: fileName})
+	assert.NoError(suite.T(), err)
+
+	content, err := ioutil.ReadAll(body)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "content", string(content))
+	assert.NoError(suite.T(), body.Close())
+
+	out, err := manager.PresignGet(fileName, time.Minute, nil)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), out.URL)
+}
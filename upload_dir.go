@@ -0,0 +1,241 @@
+package aws_manager
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultUploadDirConcurrency = 5
+)
+
+// UploadDirInput describes which files of a directory are uploaded and how.
+// Include and Exclude are glob patterns in the syntax of path.Match, where "**" also matches any number
+// of directories. A pattern without a slash is matched against the file name, other patterns against
+// the slash separated path relative to the directory. With Include set only matching files are uploaded,
+// Exclude wins over Include and skips whole directories as well.
+// Defaults is copied for every file, its Body, Path and FileName are ignored.
+type UploadDirInput struct {
+	Concurrency int
+	Defaults    UploadInput
+	Exclude     []string
+	FailFast    bool
+	Include     []string
+}
+
+type UploadDirOutput struct {
+	Files []*UploadDirResult
+}
+
+// UploadDirResult is the outcome of one file, Path is relative to the uploaded directory.
+type UploadDirResult struct {
+	Path     string
+	FileName string
+	Size     int64
+	Output   *s3manager.UploadOutput
+	Err      error
+}
+
+// UploadDir uploads the regular files under localDir to keys made of keyPrefix and their relative paths,
+// using up to Concurrency uploads at once. A failed file does not stop the others and is reported in
+// the output only, unless FailFast is set, then the remaining uploads are canceled and the first error
+// is returned together with the results collected so far.
+func (m *AwsManager) UploadDir(
+	ctx context.Context,
+	localDir string,
+	keyPrefix string,
+	in *UploadDirInput,
+) (*UploadDirOutput, error) {
+	if in == nil {
+		in = &UploadDirInput{}
+	}
+
+	if err := validatePatterns(in.Include, in.Exclude); err != nil {
+		return nil, err
+	}
+
+	local, err := walkDir(localDir, in.Include, in.Exclude)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*UploadDirResult, len(local))
+
+	for i, v := range local {
+		files[i] = &UploadDirResult{Path: v.Path, FileName: uploadDirKey(keyPrefix, v.Path), Size: v.Size}
+	}
+
+	concurrency := in.Concurrency
+
+	if concurrency <= 0 {
+		concurrency = defaultUploadDirConcurrency
+	}
+
+	err = runPool(ctx, concurrency, len(files), in.FailFast, func(ctx context.Context, i int) error {
+		return m.uploadDirFile(ctx, localDir, in, files[i])
+	})
+
+	return &UploadDirOutput{Files: files}, err
+}
+
+// Failed returns the results of the files which were not uploaded.
+func (m *UploadDirOutput) Failed() []*UploadDirResult {
+	var failed []*UploadDirResult
+
+	for _, v := range m.Files {
+		if v.Err != nil {
+			failed = append(failed, v)
+		}
+	}
+
+	return failed
+}
+
+func (m *AwsManager) uploadDirFile(
+	ctx context.Context,
+	localDir string,
+	in *UploadDirInput,
+	file *UploadDirResult,
+) error {
+	if file.Err = ctx.Err(); file.Err != nil {
+		return file.Err
+	}
+
+	fileIn := in.Defaults
+	fileIn.Body = nil
+	fileIn.Path = filepath.Join(localDir, filepath.FromSlash(file.Path))
+	fileIn.FileName = file.FileName
+
+	file.Output, file.Err = m.Upload(ctx, &fileIn)
+
+	return file.Err
+}
+
+func validatePatterns(include, exclude []string) error {
+	for _, patterns := range [][]string{include, exclude} {
+		for _, pattern := range patterns {
+			for _, segment := range strings.Split(pattern, "/") {
+				if _, err := path.Match(segment, ""); err != nil {
+					return fmt.Errorf("invalid pattern %q: %s", pattern, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// walkDir collects the regular files under localDir passing the include and exclude patterns,
+// sorted by their relative paths.
+func walkDir(localDir string, include, exclude []string) ([]*SyncFile, error) {
+	var files []*SyncFile
+
+	err := filepath.Walk(localDir, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(localDir, name)
+
+		if err != nil {
+			return err
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		if info.IsDir() {
+			if matchAny(exclude, rel) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !info.Mode().IsRegular() || matchAny(exclude, rel) {
+			return nil
+		}
+
+		if len(include) > 0 && !matchAny(include, rel) {
+			return nil
+		}
+
+		files = append(files, &SyncFile{Path: rel, LocalPath: name, Size: info.Size(), ModTime: info.ModTime()})
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	return files, nil
+}
+
+func uploadDirKey(keyPrefix, rel string) string {
+	if keyPrefix != "" && !strings.HasSuffix(keyPrefix, "/") {
+		keyPrefix += "/"
+	}
+
+	return keyPrefix + rel
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+				return true
+			}
+
+			continue
+		}
+
+		if matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchSegments matches the path segments against the pattern segments, "**" takes zero or more segments.
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(segments) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+
+		pattern = pattern[1:]
+		segments = segments[1:]
+	}
+
+	return len(segments) == 0
+}
@@ -0,0 +1,144 @@
+package aws_manager
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/paysuper/paysuper-aws-manager/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func (suite *AwsManagerTestSuite) newUploadDir(files ...string) string {
+	dir := suite.newDownloadDir()
+
+	for _, name := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(suite.T(), os.MkdirAll(filepath.Dir(name), 0755))
+		assert.NoError(suite.T(), ioutil.WriteFile(name, []byte(filepath.Base(name)), 0644))
+	}
+
+	return dir
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_UploadDir_Ok() {
+	dir := suite.newUploadDir(
+		"2019-08/merchant-1/report.csv",
+		"2019-08/merchant-1/report.pdf",
+		"2019-08/merchant-2/report.csv",
+		"2019-08/tmp/report.csv",
+		"summary.csv",
+		".DS_Store",
+	)
+	defer os.RemoveAll(dir)
+
+	out, err := suite.newStandInManager().UploadDir(context.TODO(), dir, "reports", &UploadDirInput{
+		Concurrency: 2,
+		Defaults:    UploadInput{ContentType: "text/csv", Metadata: map[string]string{"source": "export"}},
+		Exclude:     []string{".*", "2019-08/tmp"},
+		Include:     []string{"**/*.csv"},
+	})
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), out.Failed())
+	assert.Len(suite.T(), out.Files, 3)
+
+	assert.Equal(suite.T(), "2019-08/merchant-1/report.csv", out.Files[0].Path)
+	assert.Equal(suite.T(), "reports/2019-08/merchant-1/report.csv", out.Files[0].FileName)
+	assert.Equal(suite.T(), int64(len("report.csv")), out.Files[0].Size)
+	assert.NotNil(suite.T(), out.Files[0].Output)
+
+	assert.Equal(suite.T(), []string{
+		"reports/2019-08/merchant-1/report.csv",
+		"reports/2019-08/merchant-2/report.csv",
+		"reports/summary.csv",
+	}, suite.s3Server.Keys(standInBucket))
+
+	object, ok := suite.s3Server.Object(standInBucket, "reports/summary.csv")
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), []byte("summary.csv"), object.Body)
+	assert.Equal(suite.T(), "text/csv", object.ContentType)
+	assert.Equal(suite.T(), "export", object.Metadata["Source"])
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_UploadDir_ContinuesOnFailure() {
+	dir := suite.newUploadDir("a.csv", "b.csv", "c.csv")
+	defer os.RemoveAll(dir)
+
+	isKey := func(key string) interface{} {
+		return mock.MatchedBy(func(in *s3manager.UploadInput) bool {
+			return aws.StringValue(in.Key) == key
+		})
+	}
+
+	mockUploader := &test.UploaderAPI{}
+	mockUploader.On("UploadWithContext", mock.Anything, isKey("b.csv"), mock.Anything).
+		Return(nil, errors.New("upload failed"))
+	mockUploader.On("UploadWithContext", mock.Anything, mock.Anything, mock.Anything).
+		Return(&s3manager.UploadOutput{}, nil)
+	suite.awsManager.(*AwsManager).awsUploader = mockUploader
+
+	out, err := suite.awsManager.UploadDir(context.TODO(), dir, "", &UploadDirInput{Concurrency: 1})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), out.Files, 3)
+	assert.Len(suite.T(), mockUploader.Calls, 3)
+
+	failed := out.Failed()
+	assert.Len(suite.T(), failed, 1)
+	assert.Equal(suite.T(), "b.csv", failed[0].FileName)
+	assert.EqualError(suite.T(), failed[0].Err, "upload failed")
+	assert.Nil(suite.T(), failed[0].Output)
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_UploadDir_FailFast() {
+	dir := suite.newUploadDir("a.csv", "b.csv", "c.csv")
+	defer os.RemoveAll(dir)
+
+	out, err := suite.newStandInManager(MaxRetries(0)).UploadDir(context.TODO(), dir, "reports/", &UploadDirInput{
+		Concurrency: 1,
+		Defaults:    UploadInput{Bucket: "missing-bucket"},
+		FailFast:    true,
+	})
+	assert.True(suite.T(), IsBucketNotFound(err))
+	assert.Len(suite.T(), out.Files, 3)
+	assert.True(suite.T(), IsBucketNotFound(out.Files[0].Err))
+	assert.Equal(suite.T(), "reports/a.csv", out.Files[0].FileName)
+
+	for _, v := range out.Files[1:] {
+		assert.Equal(suite.T(), context.Canceled, v.Err)
+	}
+}
+
+func (suite *AwsManagerTestSuite) TestAwsManager_UploadDir_Errors() {
+	_, err := suite.awsManager.UploadDir(context.TODO(), "./not_exist", "", nil)
+	assert.True(suite.T(), os.IsNotExist(err))
+
+	_, err = suite.awsManager.UploadDir(context.TODO(), "./test", "", &UploadDirInput{Include: []string{"**/[a-"}})
+	assert.EqualError(suite.T(), err, `invalid pattern "**/[a-": syntax error in pattern`)
+}
+
+func (suite *AwsManagerTestSuite) TestMatchAny() {
+	cases := []struct {
+		pattern string
+		rel     string
+		match   bool
+	}{
+		{"*.csv", "report.csv", true},
+		{"*.csv", "2019/08/report.csv", true},
+		{"*.csv", "report.pdf", false},
+		{"2019/*/report.csv", "2019/08/report.csv", true},
+		{"2019/*.csv", "2019/08/report.csv", false},
+		{"2019/**", "2019/08/report.csv", true},
+		{"**/08/*.csv", "2019/08/report.csv", true},
+		{"**/report.csv", "report.csv", true},
+		{"2019/**/report.csv", "2019/report.csv", true},
+		{"2019/**/report.csv", "2020/08/report.csv", false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(suite.T(), c.match, matchAny([]string{c.pattern}, c.rel), c.pattern+" "+c.rel)
+	}
+}